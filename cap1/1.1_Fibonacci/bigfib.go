@@ -0,0 +1,79 @@
+package main
+
+import (
+	"log"
+	"math/big"
+	"math/bits"
+	"sync"
+)
+
+type fibCache struct {
+	mu   sync.Mutex
+	memo []*big.Int
+}
+
+func (c *fibCache) init() {
+	c.memo = []*big.Int{big.NewInt(0), big.NewInt(1)}
+}
+
+func (c *fibCache) fib(n int) *big.Int {
+	if n < 0 {
+		log.Fatal("n must be >= 0")
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.memo == nil {
+		c.init()
+	}
+	for i := len(c.memo); i <= n; i++ {
+		c.memo = append(c.memo, new(big.Int).Add(c.memo[i-1], c.memo[i-2]))
+	}
+	return new(big.Int).Set(c.memo[n])
+}
+
+func fibFastDoubling(n int) *big.Int {
+	if n < 0 {
+		log.Fatal("n must be >= 0")
+	}
+	a := big.NewInt(0) // F(k)
+	b := big.NewInt(1) // F(k+1)
+	t := new(big.Int)
+	for bit := bits.Len(uint(n)) - 1; bit >= 0; bit-- {
+		// F(2k) = F(k) * (2*F(k+1) - F(k))
+		// F(2k+1) = F(k)^2 + F(k+1)^2
+		c := new(big.Int).Lsh(b, 1)
+		c.Sub(c, a)
+		c.Mul(c, a)
+		d := new(big.Int).Mul(a, a)
+		d.Add(d, t.Mul(b, b))
+		if n>>uint(bit)&1 == 0 {
+			a, b = c, d
+		} else {
+			a, b = d, c.Add(c, d)
+		}
+	}
+	return a
+}
+
+func fibMatrix(n int) *big.Int {
+	return fibonacciRecurrence().nth(n)
+}
+
+type fibIterator struct {
+	current *big.Int
+	next    *big.Int
+}
+
+func (it *fibIterator) init() {
+	it.current = big.NewInt(0)
+	it.next = big.NewInt(1)
+}
+
+func (it *fibIterator) nextValue() *big.Int {
+	if it.current == nil {
+		it.init()
+	}
+	value := new(big.Int).Set(it.current)
+	it.current, it.next = it.next, it.current.Add(it.current, it.next)
+	return value
+}
@@ -1,6 +1,10 @@
 package main
 
-import "fmt"
+import (
+	"fmt"
+	"math/big"
+	"sync"
+)
 
 var memo = map[int]int{0: 0, 1: 1}
 
@@ -13,4 +17,53 @@ func fib3(n int) int {
 
 func main() {
 	fmt.Println(fib3(50))
+
+	cache := fibCache{}
+	cache.init()
+	var wg sync.WaitGroup
+	for _, n := range []int{100, 500, 1000, 200} {
+		wg.Add(1)
+		go func(n int) {
+			defer wg.Done()
+			cache.fib(n)
+		}(n)
+	}
+	wg.Wait()
+	fmt.Println(cache.fib(100))
+
+	n := 100000
+	fd := fibFastDoubling(n)
+	fm := fibMatrix(n)
+	fc := cache.fib(n)
+	fmt.Printf("fib(%d) has %d digits\n", n, len(fd.String()))
+	fmt.Println("fast doubling == matrix:", fd.Cmp(fm) == 0)
+	fmt.Println("fast doubling == memo:", fd.Cmp(fc) == 0)
+
+	it := fibIterator{}
+	it.init()
+	for i := 0; i < 15; i++ {
+		fmt.Print(it.nextValue(), " ")
+	}
+	fmt.Println()
+
+	lucas := lucasRecurrence()
+	fmt.Println("Lucas:", lucas.terms(10), "L(1000) mod 10^9+7 =", new(big.Int).Mod(lucas.nth(1000), big.NewInt(1000000007)))
+	tribonacci := tribonacciRecurrence()
+	fmt.Println("Tribonacci:", tribonacci.terms(10), "T(100) =", tribonacci.nth(100))
+	pell := pellRecurrence()
+	fmt.Println("Pell:", pell.terms(10), "P(100) =", pell.nth(100))
+
+	custom := recurrence{}
+	custom.init([]int64{0, 1, 1}, []int64{3, 0, 2}) // Perrin numbers
+	custom.setModulus(1000000007)
+	fmt.Println("Perrin mod 10^9+7:", custom.terms(10), "P(10^18) =", custom.nth(1000000000000000000))
+
+	for _, m := range []int64{2, 3, 10, 100, 1000} {
+		fmt.Printf("Pisano period of %d: %d\n", m, pisanoPeriod(m))
+	}
+	withTail := recurrence{}
+	withTail.init([]int64{2}, []int64{1})
+	withTail.setModulus(12)
+	mu, lambda := withTail.period()
+	fmt.Printf("2^n mod 12: %v prefix %d, period %d\n", withTail.terms(8), mu, lambda)
 }
@@ -0,0 +1,245 @@
+package main
+
+import (
+	"log"
+	"math/big"
+)
+
+type bigMatrix [][]*big.Int
+
+func identityMatrix(k int) bigMatrix {
+	m := zeroMatrix(k)
+	for i := 0; i < k; i++ {
+		m[i][i].SetInt64(1)
+	}
+	return m
+}
+
+func zeroMatrix(k int) bigMatrix {
+	m := make(bigMatrix, k)
+	for i := range m {
+		m[i] = make([]*big.Int, k)
+		for j := range m[i] {
+			m[i][j] = new(big.Int)
+		}
+	}
+	return m
+}
+
+// mul multiplies two square matrices, reducing every entry by modulus when it is not nil.
+func (m bigMatrix) mul(o bigMatrix, modulus *big.Int) bigMatrix {
+	k := len(m)
+	ret := zeroMatrix(k)
+	t := new(big.Int)
+	for i := 0; i < k; i++ {
+		for l := 0; l < k; l++ {
+			if m[i][l].Sign() == 0 {
+				continue
+			}
+			for j := 0; j < k; j++ {
+				ret[i][j].Add(ret[i][j], t.Mul(m[i][l], o[l][j]))
+			}
+		}
+		if modulus != nil {
+			for j := 0; j < k; j++ {
+				ret[i][j].Mod(ret[i][j], modulus)
+			}
+		}
+	}
+	return ret
+}
+
+func (m bigMatrix) pow(n int, modulus *big.Int) bigMatrix {
+	result := identityMatrix(len(m))
+	base := m
+	for ; n > 0; n >>= 1 {
+		if n&1 == 1 {
+			result = result.mul(base, modulus)
+		}
+		if n > 1 {
+			base = base.mul(base, modulus)
+		}
+	}
+	return result
+}
+
+// a(n) = coefficients[0]*a(n-1) + coefficients[1]*a(n-2) + ... + coefficients[k-1]*a(n-k)
+type recurrence struct {
+	coefficients []*big.Int
+	initial      []*big.Int // a(0) ... a(k-1)
+	modulus      *big.Int   // nil means the terms are not reduced
+}
+
+func (r *recurrence) init(coefficients []int64, initial []int64) {
+	if len(coefficients) == 0 || len(coefficients) != len(initial) {
+		log.Fatal("a recurrence needs as many initial terms as coefficients")
+	}
+	r.coefficients = []*big.Int{}
+	r.initial = []*big.Int{}
+	for i := range coefficients {
+		r.coefficients = append(r.coefficients, big.NewInt(coefficients[i]))
+		r.initial = append(r.initial, big.NewInt(initial[i]))
+	}
+	r.modulus = nil
+}
+
+func (r *recurrence) setModulus(m int64) {
+	if m < 1 {
+		log.Fatal("modulus must be >= 1")
+	}
+	r.modulus = big.NewInt(m)
+}
+
+func (r recurrence) order() int {
+	return len(r.coefficients)
+}
+
+func (r recurrence) reduce(x *big.Int) *big.Int {
+	if r.modulus != nil {
+		x.Mod(x, r.modulus)
+	}
+	return x
+}
+
+// companion returns the k x k matrix that maps [a(n+k-1) ... a(n)] to [a(n+k) ... a(n+1)].
+func (r recurrence) companion() bigMatrix {
+	k := r.order()
+	m := zeroMatrix(k)
+	for j := 0; j < k; j++ {
+		r.reduce(m[0][j].Set(r.coefficients[j]))
+	}
+	for i := 1; i < k; i++ {
+		m[i][i-1].SetInt64(1)
+	}
+	return m
+}
+
+func (r recurrence) nth(n int) *big.Int {
+	if n < 0 {
+		log.Fatal("n must be >= 0")
+	}
+	k := r.order()
+	if n < k {
+		return r.reduce(new(big.Int).Set(r.initial[n]))
+	}
+	// The last row of M^n applied to [a(k-1) ... a(0)] gives a(n).
+	p := r.companion().pow(n, r.modulus)
+	ret := new(big.Int)
+	t := new(big.Int)
+	for j := 0; j < k; j++ {
+		ret.Add(ret, t.Mul(p[k-1][j], r.initial[k-1-j]))
+	}
+	return r.reduce(ret)
+}
+
+func (r recurrence) firstState() []*big.Int {
+	state := []*big.Int{}
+	for _, v := range r.initial {
+		state = append(state, r.reduce(new(big.Int).Set(v)))
+	}
+	return state
+}
+
+// step advances a state window [a(n) ... a(n+k-1)] by one term, in place.
+func (r recurrence) step(state []*big.Int) {
+	k := r.order()
+	next := new(big.Int)
+	t := new(big.Int)
+	for j := 0; j < k; j++ {
+		next.Add(next, t.Mul(r.coefficients[j], state[k-1-j]))
+	}
+	copy(state, state[1:])
+	state[k-1] = r.reduce(next)
+}
+
+func (r recurrence) terms(count int) []*big.Int {
+	ret := []*big.Int{}
+	state := r.firstState()
+	for i := 0; i < count; i++ {
+		ret = append(ret, new(big.Int).Set(state[0]))
+		r.step(state)
+	}
+	return ret
+}
+
+func equalStates(a, b []*big.Int) bool {
+	for i := range a {
+		if a[i].Cmp(b[i]) != 0 {
+			return false
+		}
+	}
+	return true
+}
+
+func copyState(s []*big.Int) []*big.Int {
+	ret := make([]*big.Int, len(s))
+	for i := range s {
+		ret[i] = new(big.Int).Set(s[i])
+	}
+	return ret
+}
+
+// period finds, with Brent's cycle detection, the length mu of the non-repeating
+// prefix and the length lambda of the cycle of the sequence reduced by the modulus.
+func (r recurrence) period() (int, int) {
+	if r.modulus == nil {
+		log.Fatal("period needs a modulus")
+	}
+	power, lambda := 1, 1
+	tortoise := r.firstState()
+	hare := copyState(tortoise)
+	r.step(hare)
+	for !equalStates(tortoise, hare) {
+		if power == lambda {
+			tortoise = copyState(hare)
+			power *= 2
+			lambda = 0
+		}
+		r.step(hare)
+		lambda++
+	}
+
+	mu := 0
+	tortoise = r.firstState()
+	hare = r.firstState()
+	for i := 0; i < lambda; i++ {
+		r.step(hare)
+	}
+	for !equalStates(tortoise, hare) {
+		r.step(tortoise)
+		r.step(hare)
+		mu++
+	}
+	return mu, lambda
+}
+
+func fibonacciRecurrence() recurrence {
+	r := recurrence{}
+	r.init([]int64{1, 1}, []int64{0, 1})
+	return r
+}
+
+func lucasRecurrence() recurrence {
+	r := recurrence{}
+	r.init([]int64{1, 1}, []int64{2, 1})
+	return r
+}
+
+func tribonacciRecurrence() recurrence {
+	r := recurrence{}
+	r.init([]int64{1, 1, 1}, []int64{0, 0, 1})
+	return r
+}
+
+func pellRecurrence() recurrence {
+	r := recurrence{}
+	r.init([]int64{2, 1}, []int64{0, 1})
+	return r
+}
+
+func pisanoPeriod(m int64) int {
+	r := fibonacciRecurrence()
+	r.setModulus(m)
+	_, lambda := r.period()
+	return lambda
+}
@@ -0,0 +1,455 @@
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"compress/zlib"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+)
+
+// Archive layout, version 1:
+//
+//	"GENA" version(1 byte)
+//	sequences: one gene stream per record
+//	headers:   the header lines, one after the other
+//	qualities: one zlib stream per FASTQ record
+//	index:     uvarint count, then one entry per record
+//	footer:    uint64 headers start, qualities start, index start, "GENA"
+//
+// Offsets in the index are relative to the start of their section, so a single
+// record can be read without touching the others.
+const (
+	archiveMagic   = "GENA"
+	archiveVersion = 1
+	footerSize     = 3*8 + int64(len(archiveMagic))
+)
+
+const (
+	formatFASTA byte = '>'
+	formatFASTQ byte = '@'
+)
+
+var (
+	errBadArchive      = errors.New("not a gene archive")
+	errRecordNotFound  = errors.New("record not found")
+	errMalformedRecord = errors.New("malformed record")
+)
+
+type span struct {
+	offset int64
+	length int64
+}
+
+type indexEntry struct {
+	name     string
+	format   byte
+	bases    int64
+	sequence span
+	header   span
+	quality  span
+}
+
+// countingWriter tracks its position n relative to where it started, base in w.
+type countingWriter struct {
+	w    io.Writer
+	base int64
+	n    int64
+}
+
+func (c *countingWriter) Write(p []byte) (int, error) {
+	n, err := c.w.Write(p)
+	c.n += int64(n)
+	return n, err
+}
+
+// Seek moves within w, counting offsets from base. It lets a gene stream go back and
+// fill in its header.
+func (c *countingWriter) Seek(offset int64, whence int) (int64, error) {
+	s, ok := c.w.(io.Seeker)
+	if !ok {
+		return c.n, errNotSeekable
+	}
+	if whence == io.SeekStart {
+		offset += c.base
+	}
+	pos, err := s.Seek(offset, whence)
+	if err != nil {
+		return c.n, err
+	}
+	c.n = pos - c.base
+	return c.n, nil
+}
+
+func recordName(header string) string {
+	if fields := strings.Fields(header); len(fields) > 0 {
+		return fields[0]
+	}
+	return ""
+}
+
+type archiveWriter struct {
+	out       *countingWriter
+	headers   bytes.Buffer
+	qualities *os.File
+	buffered  *bufio.Writer
+	quality   countingWriter
+	sequence  geneWriter
+	index     []indexEntry
+}
+
+func (aw *archiveWriter) init(w io.WriteSeeker) error {
+	base, err := w.Seek(0, io.SeekCurrent)
+	if err != nil {
+		return fmt.Errorf("%w: %v", errNotSeekable, err)
+	}
+	aw.out = &countingWriter{w: w, base: base}
+	aw.index = []indexEntry{}
+	qualities, err := os.CreateTemp("", "gena-quality-")
+	if err != nil {
+		return err
+	}
+	aw.qualities = qualities
+	aw.buffered = bufio.NewWriter(qualities)
+	aw.quality = countingWriter{w: aw.buffered}
+	_, err = aw.out.Write(append([]byte(archiveMagic), archiveVersion))
+	return err
+}
+
+func (aw *archiveWriter) beginRecord(format byte, header string) error {
+	name := recordName(header)
+	if name == "" {
+		return fmt.Errorf("%w: empty record name", errMalformedRecord)
+	}
+	entry := indexEntry{name: name, format: format}
+	entry.sequence.offset = aw.out.n
+	entry.header = span{int64(aw.headers.Len()), int64(len(header))}
+	aw.headers.WriteString(header)
+	aw.index = append(aw.index, entry)
+	aw.sequence.init(aw.out)
+	return nil
+}
+
+func (aw *archiveWriter) endRecord() error {
+	if err := aw.sequence.Close(); err != nil {
+		return err
+	}
+	entry := &aw.index[len(aw.index)-1]
+	entry.sequence.length = aw.out.n - entry.sequence.offset
+	entry.bases = int64(aw.sequence.total)
+	return nil
+}
+
+func (aw *archiveWriter) writeQuality(quality []byte) error {
+	entry := &aw.index[len(aw.index)-1]
+	entry.quality.offset = aw.quality.n
+	zw := zlib.NewWriter(&aw.quality)
+	if _, err := zw.Write(quality); err != nil {
+		return err
+	}
+	if err := zw.Close(); err != nil {
+		return err
+	}
+	entry.quality.length = aw.quality.n - entry.quality.offset
+	return nil
+}
+
+func (aw *archiveWriter) Close() error {
+	defer os.Remove(aw.qualities.Name())
+	defer aw.qualities.Close()
+
+	headersStart := aw.out.n
+	if _, err := aw.headers.WriteTo(aw.out); err != nil {
+		return err
+	}
+	qualitiesStart := aw.out.n
+	if err := aw.buffered.Flush(); err != nil {
+		return err
+	}
+	if _, err := aw.qualities.Seek(0, io.SeekStart); err != nil {
+		return err
+	}
+	if _, err := io.Copy(aw.out, aw.qualities); err != nil {
+		return err
+	}
+
+	indexStart := aw.out.n
+	index := binary.AppendUvarint(nil, uint64(len(aw.index)))
+	for _, e := range aw.index {
+		index = binary.AppendUvarint(index, uint64(len(e.name)))
+		index = append(index, e.name...)
+		index = append(index, e.format)
+		index = binary.AppendUvarint(index, uint64(e.bases))
+		for _, s := range []span{e.sequence, e.header, e.quality} {
+			index = binary.AppendUvarint(index, uint64(s.offset))
+			index = binary.AppendUvarint(index, uint64(s.length))
+		}
+	}
+	index = binary.LittleEndian.AppendUint64(index, uint64(headersStart))
+	index = binary.LittleEndian.AppendUint64(index, uint64(qualitiesStart))
+	index = binary.LittleEndian.AppendUint64(index, uint64(indexStart))
+	index = append(index, archiveMagic...)
+	_, err := aw.out.Write(index)
+	return err
+}
+
+// archiveRecords reads FASTA or FASTQ records from r and writes them into an archive on w.
+// Sequences are streamed into the archive line by line.
+func archiveRecords(r io.Reader, w io.WriteSeeker) (int, error) {
+	aw := archiveWriter{}
+	if err := aw.init(w); err != nil {
+		return 0, err
+	}
+	br := bufio.NewReaderSize(r, 1<<16)
+	lineNumber := 0
+	nextLine := func() ([]byte, error) {
+		line, err := br.ReadSlice('\n')
+		if err == bufio.ErrBufferFull {
+			long := append([]byte{}, line...)
+			for err == bufio.ErrBufferFull {
+				line, err = br.ReadSlice('\n')
+				long = append(long, line...)
+			}
+			line = long
+		}
+		if err == io.EOF && len(line) > 0 {
+			err = nil
+		}
+		lineNumber++
+		return bytes.TrimRight(line, "\r\n"), err
+	}
+	fail := func(format string, args ...interface{}) (int, error) {
+		aw.Close()
+		return len(aw.index), fmt.Errorf("%w at line %d: %s", errMalformedRecord, lineNumber, fmt.Sprintf(format, args...))
+	}
+
+	line, err := nextLine()
+	for err == nil {
+		if len(line) == 0 {
+			line, err = nextLine()
+			continue
+		}
+		switch line[0] {
+		case formatFASTA:
+			if err := aw.beginRecord(formatFASTA, string(line[1:])); err != nil {
+				return fail("%v", err)
+			}
+			for {
+				line, err = nextLine()
+				if err != nil || (len(line) > 0 && (line[0] == formatFASTA || line[0] == formatFASTQ)) {
+					break
+				}
+				if _, werr := aw.sequence.Write(line); werr != nil {
+					return fail("%v", werr)
+				}
+			}
+			if err := aw.endRecord(); err != nil {
+				return fail("%v", err)
+			}
+		case formatFASTQ:
+			if err := aw.beginRecord(formatFASTQ, string(line[1:])); err != nil {
+				return fail("%v", err)
+			}
+			var sequenceLength int
+			for {
+				if line, err = nextLine(); err != nil {
+					return fail("missing '+' separator")
+				}
+				if len(line) > 0 && line[0] == '+' {
+					break
+				}
+				if _, werr := aw.sequence.Write(line); werr != nil {
+					return fail("%v", werr)
+				}
+				sequenceLength += len(line)
+			}
+			quality := []byte{}
+			for len(quality) < sequenceLength {
+				if line, err = nextLine(); err != nil {
+					return fail("quality shorter than sequence")
+				}
+				quality = append(quality, line...)
+			}
+			if len(quality) != sequenceLength {
+				return fail("quality length %d does not match sequence length %d", len(quality), sequenceLength)
+			}
+			if err := aw.endRecord(); err != nil {
+				return fail("%v", err)
+			}
+			if err := aw.writeQuality(quality); err != nil {
+				return fail("%v", err)
+			}
+			line, err = nextLine()
+		default:
+			return fail("expected '>' or '@', found %q", line[0])
+		}
+	}
+	if err != io.EOF {
+		aw.Close()
+		return len(aw.index), err
+	}
+	return len(aw.index), aw.Close()
+}
+
+type archiveReader struct {
+	r              io.ReaderAt
+	headersStart   int64
+	qualitiesStart int64
+	index          []indexEntry
+}
+
+func (ar *archiveReader) init(r io.ReaderAt, size int64) error {
+	ar.r = r
+	header := make([]byte, len(archiveMagic)+1)
+	if _, err := r.ReadAt(header, 0); err != nil || string(header[:len(archiveMagic)]) != archiveMagic {
+		return errBadArchive
+	}
+	if header[len(archiveMagic)] != archiveVersion {
+		return fmt.Errorf("%w: unsupported version %d", errBadArchive, header[len(archiveMagic)])
+	}
+	if size < int64(len(header))+footerSize {
+		return errBadArchive
+	}
+	footer := make([]byte, footerSize)
+	if _, err := r.ReadAt(footer, size-footerSize); err != nil || string(footer[24:]) != archiveMagic {
+		return errBadArchive
+	}
+	ar.headersStart = int64(binary.LittleEndian.Uint64(footer[0:]))
+	ar.qualitiesStart = int64(binary.LittleEndian.Uint64(footer[8:]))
+	indexStart := int64(binary.LittleEndian.Uint64(footer[16:]))
+	if indexStart > size-footerSize || ar.qualitiesStart > indexStart || ar.headersStart > ar.qualitiesStart {
+		return errBadArchive
+	}
+
+	index := bufio.NewReader(io.NewSectionReader(r, indexStart, size-footerSize-indexStart))
+	count, err := binary.ReadUvarint(index)
+	if err != nil {
+		return errBadArchive
+	}
+	ar.index = []indexEntry{}
+	for i := uint64(0); i < count; i++ {
+		e := indexEntry{}
+		values := make([]uint64, 7)
+		nameLength, err := binary.ReadUvarint(index)
+		if err != nil {
+			return errBadArchive
+		}
+		name := make([]byte, nameLength)
+		if _, err := io.ReadFull(index, name); err != nil {
+			return errBadArchive
+		}
+		if e.format, err = index.ReadByte(); err != nil {
+			return errBadArchive
+		}
+		for v := range values {
+			if values[v], err = binary.ReadUvarint(index); err != nil {
+				return errBadArchive
+			}
+		}
+		e.name = string(name)
+		e.bases = int64(values[0])
+		e.sequence = span{int64(values[1]), int64(values[2])}
+		e.header = span{int64(values[3]), int64(values[4])}
+		e.quality = span{int64(values[5]), int64(values[6])}
+		ar.index = append(ar.index, e)
+	}
+	return nil
+}
+
+func (ar *archiveReader) find(name string) (indexEntry, error) {
+	for _, e := range ar.index {
+		if e.name == name {
+			return e, nil
+		}
+	}
+	return indexEntry{}, fmt.Errorf("%w: %s", errRecordNotFound, name)
+}
+
+func (ar *archiveReader) header(e indexEntry) (string, error) {
+	header := make([]byte, e.header.length)
+	if _, err := ar.r.ReadAt(header, ar.headersStart+e.header.offset); err != nil {
+		return "", err
+	}
+	return string(header), nil
+}
+
+func (ar *archiveReader) sequence(e indexEntry) io.Reader {
+	gr := &geneReader{}
+	gr.init(io.NewSectionReader(ar.r, e.sequence.offset, e.sequence.length))
+	return gr
+}
+
+func (ar *archiveReader) quality(e indexEntry) ([]byte, error) {
+	zr, err := zlib.NewReader(io.NewSectionReader(ar.r, ar.qualitiesStart+e.quality.offset, e.quality.length))
+	if err != nil {
+		return nil, err
+	}
+	defer zr.Close()
+	return io.ReadAll(zr)
+}
+
+const fastaLineWidth = 60
+
+// extract writes the record called name to w in its original format.
+func (ar *archiveReader) extract(name string, w io.Writer) error {
+	e, err := ar.find(name)
+	if err != nil {
+		return err
+	}
+	header, err := ar.header(e)
+	if err != nil {
+		return err
+	}
+	bw := bufio.NewWriter(w)
+	bw.WriteByte(e.format)
+	bw.WriteString(header)
+	bw.WriteByte('\n')
+
+	sequence := ar.sequence(e)
+	if e.format == formatFASTQ {
+		if _, err := io.Copy(bw, sequence); err != nil {
+			return err
+		}
+		quality, err := ar.quality(e)
+		if err != nil {
+			return err
+		}
+		bw.WriteString("\n+\n")
+		bw.Write(quality)
+		bw.WriteByte('\n')
+		return bw.Flush()
+	}
+
+	lw := lineWrapper{w: bw, width: fastaLineWidth}
+	if _, err := io.Copy(&lw, sequence); err != nil {
+		return err
+	}
+	if lw.column > 0 {
+		bw.WriteByte('\n')
+	}
+	return bw.Flush()
+}
+
+type lineWrapper struct {
+	w      *bufio.Writer
+	width  int
+	column int
+}
+
+func (lw *lineWrapper) Write(p []byte) (int, error) {
+	for i, b := range p {
+		if err := lw.w.WriteByte(b); err != nil {
+			return i, err
+		}
+		lw.column++
+		if lw.column == lw.width {
+			lw.w.WriteByte('\n')
+			lw.column = 0
+		}
+	}
+	return len(p), nil
+}
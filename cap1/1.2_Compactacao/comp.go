@@ -1,18 +1,23 @@
 package main
 
 import (
+	"bytes"
 	"fmt"
+	"io"
+	"log"
 	"math/big"
+	"math/rand"
 	"os"
+	"strings"
 )
 
 type gene struct {
 	compressed *big.Int
 }
 
-func (g *gene) compressGene(str string) {
+func (g *gene) compressGene(str string) error {
 	g.compressed = big.NewInt(1)
-	for _, nucleotide := range str {
+	for i, nucleotide := range str {
 		g.compressed.Lsh(g.compressed, 2)
 		if nucleotide == 'A' {
 			g.compressed.Or(g.compressed, big.NewInt(0))
@@ -23,32 +28,234 @@ func (g *gene) compressGene(str string) {
 		} else if nucleotide == 'G' {
 			g.compressed.Or(g.compressed, big.NewInt(3))
 		} else {
-			os.Exit(1)
+			g.compressed = nil
+			return fmt.Errorf("%w %q at position %d", errInvalidNucleotide, nucleotide, i)
 		}
 	}
+	return nil
 }
 
 func (g *gene) deCompressGene() string {
-	str := ""
+	var str strings.Builder
 	lCompressed := g.compressed.BitLen() - 1
+	str.Grow(lCompressed / 2)
 	for i := lCompressed - 2; i >= 0; i -= 2 {
 		res := g.compressed.Bit(i) + g.compressed.Bit(i+1)*2
-		if res == 0 {
-			str = str + "A"
-		} else if res == 1 {
-			str = str + "C"
-		} else if res == 2 {
-			str = str + "T"
-		} else if res == 3 {
-			str = str + "G"
+		str.WriteByte(codeNucleotides[res])
+	}
+	return str.String()
+}
+
+// lineBreakFilter drops the line breaks of a sequence file on its way to w.
+type lineBreakFilter struct {
+	w io.Writer
+}
+
+func (f lineBreakFilter) Write(p []byte) (int, error) {
+	start := 0
+	for i, b := range p {
+		if b == '\n' || b == '\r' {
+			if _, err := f.w.Write(p[start:i]); err != nil {
+				return start, err
+			}
+			start = i + 1
+		}
+	}
+	if _, err := f.w.Write(p[start:]); err != nil {
+		return start, err
+	}
+	return len(p), nil
+}
+
+func compressFile(in, out string) error {
+	src, err := os.Open(in)
+	if err != nil {
+		return err
+	}
+	defer src.Close()
+	dst, err := os.Create(out)
+	if err != nil {
+		return err
+	}
+	defer dst.Close()
+
+	gw := geneWriter{}
+	gw.init(dst)
+	if _, err := io.Copy(lineBreakFilter{&gw}, src); err != nil {
+		return err
+	}
+	if err := gw.Close(); err != nil {
+		return err
+	}
+	return dst.Close()
+}
+
+func decompressFile(in, out string) error {
+	src, err := os.Open(in)
+	if err != nil {
+		return err
+	}
+	defer src.Close()
+	dst, err := os.Create(out)
+	if err != nil {
+		return err
+	}
+	defer dst.Close()
+
+	gr := geneReader{}
+	gr.init(src)
+	if _, err := io.Copy(dst, &gr); err != nil {
+		return err
+	}
+	return dst.Close()
+}
+
+func archiveFile(in, out string) error {
+	src, err := os.Open(in)
+	if err != nil {
+		return err
+	}
+	defer src.Close()
+	dst, err := os.Create(out)
+	if err != nil {
+		return err
+	}
+	defer dst.Close()
+
+	records, err := archiveRecords(src, dst)
+	if err != nil {
+		return err
+	}
+	fmt.Printf("%d records archived\n", records)
+	return dst.Close()
+}
+
+func openArchive(name string) (*os.File, *archiveReader, error) {
+	f, err := os.Open(name)
+	if err != nil {
+		return nil, nil, err
+	}
+	info, err := f.Stat()
+	if err != nil {
+		f.Close()
+		return nil, nil, err
+	}
+	ar := &archiveReader{}
+	if err := ar.init(f, info.Size()); err != nil {
+		f.Close()
+		return nil, nil, err
+	}
+	return f, ar, nil
+}
+
+func runCommand(args []string) error {
+	usage := fmt.Errorf("usage: compress|decompress|archive <input> <output>, extract <archive> <name>, list <archive>")
+	switch {
+	case len(args) == 3 && args[0] == "compress":
+		return compressFile(args[1], args[2])
+	case len(args) == 3 && args[0] == "decompress":
+		return decompressFile(args[1], args[2])
+	case len(args) == 3 && args[0] == "archive":
+		return archiveFile(args[1], args[2])
+	case len(args) == 3 && args[0] == "extract":
+		f, ar, err := openArchive(args[1])
+		if err != nil {
+			return err
+		}
+		defer f.Close()
+		return ar.extract(args[2], os.Stdout)
+	case len(args) == 2 && args[0] == "list":
+		f, ar, err := openArchive(args[1])
+		if err != nil {
+			return err
 		}
+		defer f.Close()
+		for _, e := range ar.index {
+			fmt.Printf("%c %s %d bases\n", e.format, e.name, e.bases)
+		}
+		return nil
 	}
-	return str
+	return usage
 }
 
 func main() {
+	if len(os.Args) > 1 {
+		if err := runCommand(os.Args[1:]); err != nil {
+			log.Fatal(err)
+		}
+		return
+	}
+
 	gene := gene{}
-	gene.compressGene("ACTGAACCTTGGACTGAACCTTGGACTGAACCTTGGACTGAACCTTGGACTGAACCTTGGACTGAACCTTGGACTGAACCTTGGACTGAACCTTGG")
+	err := gene.compressGene("ACTGAACCTTGGACTGAACCTTGGACTGAACCTTGGACTGAACCTTGGACTGAACCTTGGACTGAACCTTGGACTGAACCTTGGACTGAACCTTGG")
+	if err != nil {
+		log.Fatal(err)
+	}
 	fmt.Println(gene.compressed)
 	fmt.Println(gene.deCompressGene())
+	fmt.Println(gene.compressGene("ACTGXACT"))
+
+	rnd := rand.New(rand.NewSource(42))
+	sequence := make([]byte, 3*streamChunkSize+12345)
+	for i := range sequence {
+		sequence[i] = "ACGT"[rnd.Intn(4)]
+	}
+	copy(sequence[1000:], "NNNNNNNNNNRYKM-nnnacgtacgt")
+
+	compressed, err := os.CreateTemp("", "gene-")
+	if err != nil {
+		log.Fatal(err)
+	}
+	defer os.Remove(compressed.Name())
+	defer compressed.Close()
+	gw := geneWriter{}
+	gw.init(compressed)
+	if _, err := gw.Write(sequence); err != nil {
+		log.Fatal(err)
+	}
+	if err := gw.Close(); err != nil {
+		log.Fatal(err)
+	}
+	size, err := compressed.Seek(0, io.SeekCurrent)
+	if err == nil {
+		_, err = compressed.Seek(0, io.SeekStart)
+	}
+	if err != nil {
+		log.Fatal(err)
+	}
+	fmt.Printf("%d bases -> %d bytes\n", len(sequence), size)
+
+	gr := geneReader{}
+	gr.init(compressed)
+	decompressed, err := io.ReadAll(&gr)
+	if err != nil {
+		log.Fatal(err)
+	}
+	fmt.Println("round trip:", bytes.Equal(sequence, decompressed))
+
+	gw.init(compressed)
+	_, err = gw.Write([]byte("ACGT\nACGT"))
+	fmt.Println(err)
+
+	skewed := make([]byte, 100000)
+	for i := range skewed {
+		if rnd.Float64() < 0.7 {
+			skewed[i] = 'A'
+		} else {
+			skewed[i] = "CTG"[rnd.Intn(3)]
+		}
+	}
+	protein := make([]byte, 100000)
+	for i := range protein {
+		protein[i] = "ACDEFGHIKLMNPQRSTVWY"[rnd.Intn(20)]
+	}
+	text := []byte(strings.Repeat("O objetivo deste projeto foi refazer todos as solucoes dos problemas do livro em Go. ", 500))
+	for _, sample := range []struct {
+		name string
+		data []byte
+	}{{"uniform DNA", sequence[2000:102000]}, {"skewed DNA", skewed}, {"protein", protein}, {"text", text}} {
+		if err := compressionReport(sample.name, sample.data); err != nil {
+			log.Fatal(err)
+		}
+	}
 }
@@ -0,0 +1,30 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestCompressNewlineTerminatedFile(t *testing.T) {
+	dir := t.TempDir()
+	in := filepath.Join(dir, "seq.txt")
+	packed := filepath.Join(dir, "seq.gene")
+	out := filepath.Join(dir, "seq.out")
+	if err := os.WriteFile(in, []byte("ACGTNacgt\r\nGGCA\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if err := compressFile(in, packed); err != nil {
+		t.Fatalf("compress: %v", err)
+	}
+	if err := decompressFile(packed, out); err != nil {
+		t.Fatalf("decompress: %v", err)
+	}
+	got, err := os.ReadFile(out)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(got) != "ACGTNacgtGGCA" {
+		t.Errorf("round trip gave %q, want %q", got, "ACGTNacgtGGCA")
+	}
+}
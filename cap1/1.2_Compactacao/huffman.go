@@ -0,0 +1,267 @@
+package main
+
+import (
+	"container/heap"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"math"
+	"sort"
+)
+
+const huffmanMaxLength = 32
+
+var errCorruptHuffman = errors.New("corrupt huffman data")
+
+type huffmanNode struct {
+	weight int
+	symbol int // -1 for internal nodes
+	left   *huffmanNode
+	right  *huffmanNode
+}
+
+type huffmanQueue []*huffmanNode
+
+func (q huffmanQueue) Len() int { return len(q) }
+
+func (q huffmanQueue) Less(i, j int) bool {
+	if q[i].weight == q[j].weight {
+		return q[i].symbol < q[j].symbol
+	}
+	return q[i].weight < q[j].weight
+}
+
+func (q huffmanQueue) Swap(i, j int) { q[i], q[j] = q[j], q[i] }
+
+func (q *huffmanQueue) Push(x interface{}) { *q = append(*q, x.(*huffmanNode)) }
+
+func (q *huffmanQueue) Pop() interface{} {
+	old := *q
+	n := len(old)
+	item := old[n-1]
+	*q = old[:n-1]
+	return item
+}
+
+// huffmanCode is a canonical Huffman code: only the code length of each symbol
+// has to be stored, the codes themselves are rebuilt from the lengths.
+type huffmanCode struct {
+	lengths [256]uint8
+	codes   [256]uint32
+}
+
+func (h *huffmanCode) init(frequencies [256]int) {
+	for {
+		h.lengths = [256]uint8{}
+		if h.buildLengths(frequencies) {
+			break
+		}
+		// Too deep for 32-bit codes: flatten the distribution and try again.
+		for i := range frequencies {
+			if frequencies[i] > 0 {
+				frequencies[i] = (frequencies[i] + 1) / 2
+			}
+		}
+	}
+	h.assignCodes()
+}
+
+func (h *huffmanCode) buildLengths(frequencies [256]int) bool {
+	q := huffmanQueue{}
+	for s, f := range frequencies {
+		if f > 0 {
+			q = append(q, &huffmanNode{f, s, nil, nil})
+		}
+	}
+	if len(q) == 1 {
+		h.lengths[q[0].symbol] = 1
+		return true
+	}
+	heap.Init(&q)
+	for q.Len() > 1 {
+		a := heap.Pop(&q).(*huffmanNode)
+		b := heap.Pop(&q).(*huffmanNode)
+		heap.Push(&q, &huffmanNode{a.weight + b.weight, -1, a, b})
+	}
+	if q.Len() == 0 {
+		return true
+	}
+	var walk func(n *huffmanNode, depth int) bool
+	walk = func(n *huffmanNode, depth int) bool {
+		if n.symbol >= 0 {
+			if depth > huffmanMaxLength {
+				return false
+			}
+			h.lengths[n.symbol] = uint8(depth)
+			return true
+		}
+		return walk(n.left, depth+1) && walk(n.right, depth+1)
+	}
+	return walk(q[0], 0)
+}
+
+func (h *huffmanCode) sortedSymbols() []int {
+	symbols := []int{}
+	for s, l := range h.lengths {
+		if l > 0 {
+			symbols = append(symbols, s)
+		}
+	}
+	sort.Slice(symbols, func(i, j int) bool {
+		if h.lengths[symbols[i]] == h.lengths[symbols[j]] {
+			return symbols[i] < symbols[j]
+		}
+		return h.lengths[symbols[i]] < h.lengths[symbols[j]]
+	})
+	return symbols
+}
+
+func (h *huffmanCode) assignCodes() {
+	code := uint32(0)
+	previous := uint8(0)
+	for i, s := range h.sortedSymbols() {
+		if i > 0 {
+			code++
+		}
+		code <<= h.lengths[s] - previous
+		previous = h.lengths[s]
+		h.codes[s] = code
+	}
+}
+
+type bitWriter struct {
+	data  []byte
+	nbits uint
+}
+
+func (bw *bitWriter) write(code uint32, length uint8) {
+	for i := int(length) - 1; i >= 0; i-- {
+		if bw.nbits%8 == 0 {
+			bw.data = append(bw.data, 0)
+		}
+		if code>>uint(i)&1 == 1 {
+			bw.data[len(bw.data)-1] |= 0x80 >> (bw.nbits % 8)
+		}
+		bw.nbits++
+	}
+}
+
+// huffmanCompress encodes data as: uvarint length, uvarint number of symbols,
+// (symbol, code length) pairs, then the packed codes.
+func huffmanCompress(data []byte) []byte {
+	frequencies := [256]int{}
+	for _, b := range data {
+		frequencies[b]++
+	}
+	h := huffmanCode{}
+	h.init(frequencies)
+
+	out := binary.AppendUvarint(nil, uint64(len(data)))
+	symbols := h.sortedSymbols()
+	out = binary.AppendUvarint(out, uint64(len(symbols)))
+	for _, s := range symbols {
+		out = append(out, byte(s), h.lengths[s])
+	}
+	bw := bitWriter{data: out, nbits: uint(len(out)) * 8}
+	for _, b := range data {
+		bw.write(h.codes[b], h.lengths[b])
+	}
+	return bw.data
+}
+
+func huffmanDecompress(compressed []byte) ([]byte, error) {
+	length, n := binary.Uvarint(compressed)
+	if n <= 0 {
+		return nil, errCorruptHuffman
+	}
+	compressed = compressed[n:]
+	numSymbols, n := binary.Uvarint(compressed)
+	if n <= 0 || numSymbols > 256 || uint64(len(compressed[n:])) < 2*numSymbols {
+		return nil, errCorruptHuffman
+	}
+	compressed = compressed[n:]
+	if length > 0 && numSymbols == 0 {
+		return nil, errCorruptHuffman
+	}
+
+	h := huffmanCode{}
+	for i := uint64(0); i < numSymbols; i++ {
+		l := compressed[2*i+1]
+		if l == 0 || l > huffmanMaxLength {
+			return nil, errCorruptHuffman
+		}
+		h.lengths[compressed[2*i]] = l
+	}
+	compressed = compressed[2*numSymbols:]
+	h.assignCodes()
+
+	// Canonical decoding: for each length, the first code and where its symbols start.
+	symbols := h.sortedSymbols()
+	firstCode := [huffmanMaxLength + 2]uint32{}
+	firstIndex := [huffmanMaxLength + 2]int{}
+	count := [huffmanMaxLength + 2]int{}
+	for i := len(symbols) - 1; i >= 0; i-- {
+		l := h.lengths[symbols[i]]
+		firstCode[l] = h.codes[symbols[i]]
+		firstIndex[l] = i
+		count[l]++
+	}
+
+	out := make([]byte, 0, length)
+	bit := uint64(0)
+	totalBits := uint64(len(compressed)) * 8
+	for uint64(len(out)) < length {
+		code := uint32(0)
+		l := 0
+		for {
+			if bit >= totalBits || l == huffmanMaxLength {
+				return nil, errCorruptHuffman
+			}
+			code = code<<1 | uint32(compressed[bit/8]>>(7-bit%8)&1)
+			bit++
+			l++
+			if count[l] > 0 && code >= firstCode[l] && code-firstCode[l] < uint32(count[l]) {
+				out = append(out, byte(symbols[firstIndex[l]+int(code-firstCode[l])]))
+				break
+			}
+		}
+	}
+	return out, nil
+}
+
+func shannonEntropy(data []byte) float64 {
+	frequencies := [256]int{}
+	for _, b := range data {
+		frequencies[b]++
+	}
+	entropy := 0.0
+	for _, f := range frequencies {
+		if f > 0 {
+			p := float64(f) / float64(len(data))
+			entropy -= p * math.Log2(p)
+		}
+	}
+	return entropy
+}
+
+// compressionReport compares the Huffman coder with the fixed 2-bit gene encoding.
+func compressionReport(name string, data []byte) error {
+	compressed := huffmanCompress(data)
+	decompressed, err := huffmanDecompress(compressed)
+	if err != nil {
+		return err
+	}
+	if string(decompressed) != string(data) {
+		return fmt.Errorf("%s: huffman round trip failed", name)
+	}
+	symbols := float64(len(data))
+	fmt.Printf("%s: %d symbols, entropy %.3f bits/symbol\n", name, len(data), shannonEntropy(data))
+	fmt.Printf("  huffman:      %.3f bits/symbol (%d bytes)\n", float64(len(compressed)*8)/symbols, len(compressed))
+	g := gene{}
+	if err := g.compressGene(string(data)); err != nil {
+		fmt.Printf("  compressGene: not applicable (%v)\n", err)
+	} else {
+		fmt.Printf("  compressGene: %.3f bits/symbol\n", float64(g.compressed.BitLen()-1)/symbols)
+	}
+	return nil
+}
@@ -0,0 +1,429 @@
+package main
+
+import (
+	"bufio"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"hash"
+	"hash/crc32"
+	"io"
+)
+
+// Stream format, version 2:
+//
+//	header:  "GENE" version(1 byte) flags(1 byte) uint64 total bases, uint32 CRC-32 of the whole sequence
+//	chunk:   uvarint bases (0 ends the stream)
+//	         uvarint escapes, then for each: uvarint gap, uvarint run, symbol byte
+//	         uvarint lowercase runs, then for each: uvarint gap, uvarint run
+//	         ceil(bases/4) bytes of 2-bit codes, first base in the high bits
+//	         uint32 CRC-32 of the chunk bases
+//	trailer: uint64 total bases, uint32 CRC-32 of the whole sequence
+//
+// The writer fills in the header length and CRC on Close, so it needs an output it can
+// seek back on, such as a file. The trailer repeats them for a reader that reaches the
+// end. Version 1 streams have no flags or summary in the header, and a reader ignores a
+// version 2 summary whose headerSummary flag is not set.
+//
+// Escaped bases (N and the other IUPAC codes) are stored as A in the packed data.
+const (
+	streamMagic     = "GENE"
+	streamVersion   = 2
+	streamChunkSize = 1 << 20
+	headerSummary   = 1
+	summarySize     = 12
+)
+
+var (
+	errInvalidNucleotide  = errors.New("invalid nucleotide")
+	errBadMagic           = errors.New("not a compressed gene stream")
+	errUnsupportedVersion = errors.New("unsupported gene stream version")
+	errChecksum           = errors.New("gene stream checksum mismatch")
+	errLength             = errors.New("gene stream length mismatch")
+	errClosed             = errors.New("gene writer is closed")
+	errNotSeekable        = errors.New("gene stream output cannot seek")
+)
+
+var nucleotideCodes = [256]int8{}
+var codeNucleotides = [4]byte{'A', 'C', 'T', 'G'}
+
+func init() {
+	for i := range nucleotideCodes {
+		nucleotideCodes[i] = -1
+	}
+	for code, n := range codeNucleotides {
+		nucleotideCodes[n] = int8(code)
+		nucleotideCodes[n|0x20] = int8(code)
+	}
+}
+
+func isIUPAC(b byte) bool {
+	switch b | 0x20 {
+	case 'r', 'y', 's', 'w', 'k', 'm', 'b', 'd', 'h', 'v', 'n':
+		return true
+	}
+	return b == '-'
+}
+
+func isLower(b byte) bool {
+	return b >= 'a' && b <= 'z'
+}
+
+type geneWriter struct {
+	w      *bufio.Writer
+	seeker io.WriteSeeker
+	start  int64
+	chunk  []byte
+	total  uint64
+	crc    hash.Hash32
+	closed bool
+	err    error
+}
+
+// init starts a stream at the current position of w. Output that cannot seek, such as a
+// pipe, fails on the first Write or Close.
+func (gw *geneWriter) init(w io.WriteSeeker) {
+	gw.w = bufio.NewWriter(w)
+	gw.seeker = w
+	gw.chunk = gw.chunk[:0]
+	gw.total = 0
+	gw.crc = crc32.NewIEEE()
+	gw.closed = false
+	gw.err = nil
+	if gw.start, gw.err = w.Seek(0, io.SeekCurrent); gw.err != nil {
+		gw.err = fmt.Errorf("%w: %v", errNotSeekable, gw.err)
+	}
+	gw.putBytes([]byte(streamMagic))
+	gw.putBytes([]byte{streamVersion, headerSummary})
+	gw.putBytes(make([]byte, summarySize))
+}
+
+func (gw *geneWriter) summary() []byte {
+	var summary [summarySize]byte
+	binary.LittleEndian.PutUint64(summary[:8], gw.total)
+	binary.LittleEndian.PutUint32(summary[8:], gw.crc.Sum32())
+	return summary[:]
+}
+
+func (gw *geneWriter) Write(p []byte) (int, error) {
+	if gw.closed {
+		return 0, errClosed
+	}
+	if gw.err != nil {
+		return 0, gw.err
+	}
+	for i, b := range p {
+		if nucleotideCodes[b] < 0 && !isIUPAC(b) {
+			return i, fmt.Errorf("%w %q at position %d", errInvalidNucleotide, b, gw.total+uint64(len(gw.chunk)))
+		}
+		gw.chunk = append(gw.chunk, b)
+		if len(gw.chunk) == streamChunkSize {
+			if err := gw.flushChunk(); err != nil {
+				return i + 1, err
+			}
+		}
+	}
+	return len(p), nil
+}
+
+func (gw *geneWriter) putUvarint(x uint64) {
+	if gw.err != nil {
+		return
+	}
+	var buf [binary.MaxVarintLen64]byte
+	_, gw.err = gw.w.Write(buf[:binary.PutUvarint(buf[:], x)])
+}
+
+func (gw *geneWriter) putBytes(b []byte) {
+	if gw.err != nil {
+		return
+	}
+	_, gw.err = gw.w.Write(b)
+}
+
+type run struct {
+	start  int
+	length int
+	symbol byte
+}
+
+func (gw *geneWriter) flushChunk() error {
+	if len(gw.chunk) == 0 {
+		return gw.err
+	}
+	escapes := []run{}
+	lowercase := []run{}
+	packed := make([]byte, (len(gw.chunk)+3)/4)
+	for i, b := range gw.chunk {
+		code := nucleotideCodes[b]
+		if code < 0 {
+			if last := len(escapes) - 1; last >= 0 && escapes[last].symbol == b && escapes[last].start+escapes[last].length == i {
+				escapes[last].length++
+			} else {
+				escapes = append(escapes, run{i, 1, b})
+			}
+			continue
+		}
+		packed[i/4] |= byte(code) << uint(6-2*(i%4))
+		if isLower(b) {
+			if last := len(lowercase) - 1; last >= 0 && lowercase[last].start+lowercase[last].length == i {
+				lowercase[last].length++
+			} else {
+				lowercase = append(lowercase, run{i, 1, 0})
+			}
+		}
+	}
+
+	gw.putUvarint(uint64(len(gw.chunk)))
+	gw.putUvarint(uint64(len(escapes)))
+	previous := 0
+	for _, e := range escapes {
+		gw.putUvarint(uint64(e.start - previous))
+		gw.putUvarint(uint64(e.length))
+		gw.putBytes([]byte{e.symbol})
+		previous = e.start + e.length
+	}
+	gw.putUvarint(uint64(len(lowercase)))
+	previous = 0
+	for _, l := range lowercase {
+		gw.putUvarint(uint64(l.start - previous))
+		gw.putUvarint(uint64(l.length))
+		previous = l.start + l.length
+	}
+	gw.putBytes(packed)
+	var sum [4]byte
+	binary.LittleEndian.PutUint32(sum[:], crc32.ChecksumIEEE(gw.chunk))
+	gw.putBytes(sum[:])
+
+	gw.crc.Write(gw.chunk)
+	gw.total += uint64(len(gw.chunk))
+	gw.chunk = gw.chunk[:0]
+	return gw.err
+}
+
+// Close writes the last chunk, the trailer and the header summary. It does not close the
+// underlying writer.
+func (gw *geneWriter) Close() error {
+	if gw.closed {
+		return gw.err
+	}
+	gw.closed = true
+	gw.flushChunk()
+	gw.putUvarint(0)
+	gw.putBytes(gw.summary())
+	if gw.err != nil {
+		return gw.err
+	}
+	if gw.err = gw.w.Flush(); gw.err != nil {
+		return gw.err
+	}
+	end, err := gw.seeker.Seek(0, io.SeekCurrent)
+	if err == nil {
+		_, err = gw.seeker.Seek(gw.start+int64(len(streamMagic))+2, io.SeekStart)
+	}
+	if err == nil {
+		_, err = gw.seeker.Write(gw.summary())
+	}
+	if err == nil {
+		_, err = gw.seeker.Seek(end, io.SeekStart)
+	}
+	gw.err = err
+	return err
+}
+
+type geneReader struct {
+	r       *bufio.Reader
+	pending []byte
+	total   uint64
+	// length and sum come from the header when known is set.
+	known  bool
+	length uint64
+	sum    uint32
+	crc    hash.Hash32
+	done   bool
+	err    error
+}
+
+func (gr *geneReader) init(r io.Reader) {
+	gr.r = bufio.NewReader(r)
+	gr.pending = nil
+	gr.total = 0
+	gr.crc = crc32.NewIEEE()
+	gr.done = false
+	gr.err = nil
+	gr.known = false
+
+	header := make([]byte, len(streamMagic)+1)
+	if _, err := io.ReadFull(gr.r, header); err != nil {
+		gr.err = errBadMagic
+		return
+	}
+	if string(header[:len(streamMagic)]) != streamMagic {
+		gr.err = errBadMagic
+		return
+	}
+	switch version := header[len(streamMagic)]; version {
+	case 1:
+	case streamVersion:
+		var fields [1 + summarySize]byte
+		if _, err := io.ReadFull(gr.r, fields[:]); err != nil {
+			gr.err = noEOF(err)
+			return
+		}
+		if fields[0]&headerSummary != 0 {
+			gr.known = true
+			gr.length = binary.LittleEndian.Uint64(fields[1:9])
+			gr.sum = binary.LittleEndian.Uint32(fields[9:])
+		}
+	default:
+		gr.err = fmt.Errorf("%w %d", errUnsupportedVersion, version)
+	}
+}
+
+// sequenceLength returns the number of bases announced by the header, if it has one.
+func (gr *geneReader) sequenceLength() (uint64, bool) {
+	return gr.length, gr.known
+}
+
+func (gr *geneReader) Read(p []byte) (int, error) {
+	for len(gr.pending) == 0 {
+		if gr.err != nil {
+			return 0, gr.err
+		}
+		if gr.done {
+			return 0, io.EOF
+		}
+		gr.err = gr.readChunk()
+		if gr.err == io.ErrUnexpectedEOF && gr.known {
+			gr.err = fmt.Errorf("%w: stream truncated after %d of %d bases", errLength, gr.total, gr.length)
+		}
+	}
+	n := copy(p, gr.pending)
+	gr.pending = gr.pending[n:]
+	return n, nil
+}
+
+func (gr *geneReader) uvarint() (int, error) {
+	x, err := binary.ReadUvarint(gr.r)
+	if err != nil {
+		return 0, noEOF(err)
+	}
+	if x > streamChunkSize {
+		return 0, fmt.Errorf("corrupt gene stream: value %d out of range", x)
+	}
+	return int(x), nil
+}
+
+func noEOF(err error) error {
+	if err == io.EOF {
+		return io.ErrUnexpectedEOF
+	}
+	return err
+}
+
+func (gr *geneReader) readChunk() error {
+	n, err := gr.uvarint()
+	if err != nil {
+		return err
+	}
+	if n == 0 {
+		return gr.readTrailer()
+	}
+	if gr.known && gr.total+uint64(n) > gr.length {
+		return fmt.Errorf("%w: more than the %d bases in the header", errLength, gr.length)
+	}
+
+	numEscapes, err := gr.uvarint()
+	if err != nil {
+		return err
+	}
+	escapes := []run{}
+	position := 0
+	for i := 0; i < numEscapes; i++ {
+		gap, err := gr.uvarint()
+		if err != nil {
+			return err
+		}
+		length, err := gr.uvarint()
+		if err != nil {
+			return err
+		}
+		symbol, err := gr.r.ReadByte()
+		if err != nil {
+			return noEOF(err)
+		}
+		escapes = append(escapes, run{position + gap, length, symbol})
+		position += gap + length
+	}
+	numLowercase, err := gr.uvarint()
+	if err != nil {
+		return err
+	}
+	lowercase := []run{}
+	position = 0
+	for i := 0; i < numLowercase; i++ {
+		gap, err := gr.uvarint()
+		if err != nil {
+			return err
+		}
+		length, err := gr.uvarint()
+		if err != nil {
+			return err
+		}
+		lowercase = append(lowercase, run{position + gap, length, 0})
+		position += gap + length
+	}
+
+	packed := make([]byte, (n+3)/4)
+	if _, err := io.ReadFull(gr.r, packed); err != nil {
+		return noEOF(err)
+	}
+	var sum [4]byte
+	if _, err := io.ReadFull(gr.r, sum[:]); err != nil {
+		return noEOF(err)
+	}
+
+	bases := make([]byte, n)
+	for i := range bases {
+		bases[i] = codeNucleotides[packed[i/4]>>uint(6-2*(i%4))&3]
+	}
+	for _, l := range lowercase {
+		if l.start+l.length > n {
+			return fmt.Errorf("corrupt gene stream: lowercase run past the end of the chunk")
+		}
+		for i := l.start; i < l.start+l.length; i++ {
+			bases[i] |= 0x20
+		}
+	}
+	for _, e := range escapes {
+		if e.start+e.length > n {
+			return fmt.Errorf("corrupt gene stream: escape run past the end of the chunk")
+		}
+		for i := e.start; i < e.start+e.length; i++ {
+			bases[i] = e.symbol
+		}
+	}
+	if crc32.ChecksumIEEE(bases) != binary.LittleEndian.Uint32(sum[:]) {
+		return errChecksum
+	}
+
+	gr.crc.Write(bases)
+	gr.total += uint64(n)
+	gr.pending = bases
+	return nil
+}
+
+func (gr *geneReader) readTrailer() error {
+	var trailer [12]byte
+	if _, err := io.ReadFull(gr.r, trailer[:]); err != nil {
+		return noEOF(err)
+	}
+	if binary.LittleEndian.Uint64(trailer[:8]) != gr.total || gr.known && gr.length != gr.total {
+		return errLength
+	}
+	if binary.LittleEndian.Uint32(trailer[8:]) != gr.crc.Sum32() || gr.known && gr.sum != gr.crc.Sum32() {
+		return errChecksum
+	}
+	gr.done = true
+	return nil
+}
@@ -0,0 +1,465 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"math/rand"
+	"sort"
+	"strings"
+)
+
+var errInvalidKey = errors.New("invalid key")
+
+// English letter frequencies, A to Z.
+var englishFrequencies = [26]float64{
+	0.08167, 0.01492, 0.02782, 0.04253, 0.12702, 0.02228, 0.02015, 0.06094, 0.06966, 0.00153,
+	0.00772, 0.04025, 0.02406, 0.06749, 0.07507, 0.01929, 0.00095, 0.05987, 0.06327, 0.09056,
+	0.02758, 0.00978, 0.02360, 0.00150, 0.01974, 0.00074,
+}
+
+func letterIndex(r byte) (int, bool) {
+	switch {
+	case r >= 'A' && r <= 'Z':
+		return int(r - 'A'), true
+	case r >= 'a' && r <= 'z':
+		return int(r - 'a'), true
+	}
+	return 0, false
+}
+
+// mapLetters applies f to every ASCII letter of s, keeping its case. Other bytes are copied.
+// i counts letters only, so keyed ciphers skip spaces and punctuation.
+func mapLetters(s string, f func(letter int, i int) int) string {
+	ret := []byte(s)
+	i := 0
+	for k, r := range ret {
+		l, ok := letterIndex(r)
+		if !ok {
+			continue
+		}
+		base := byte('A')
+		if r >= 'a' {
+			base = 'a'
+		}
+		ret[k] = base + byte(mod26(f(l, i)))
+		i++
+	}
+	return string(ret)
+}
+
+func mod26(x int) int {
+	return ((x % 26) + 26) % 26
+}
+
+func caesarEncrypt(s string, shift int) string {
+	return mapLetters(s, func(l, _ int) int { return l + shift })
+}
+
+func caesarDecrypt(s string, shift int) string {
+	return caesarEncrypt(s, -shift)
+}
+
+func vigenereKey(key string) ([]int, error) {
+	shifts := []int{}
+	for i := 0; i < len(key); i++ {
+		l, ok := letterIndex(key[i])
+		if !ok {
+			return nil, errInvalidKey
+		}
+		shifts = append(shifts, l)
+	}
+	if len(shifts) == 0 {
+		return nil, errInvalidKey
+	}
+	return shifts, nil
+}
+
+func vigenereEncrypt(s string, key string) (string, error) {
+	shifts, err := vigenereKey(key)
+	if err != nil {
+		return "", err
+	}
+	return mapLetters(s, func(l, i int) int { return l + shifts[i%len(shifts)] }), nil
+}
+
+func vigenereDecrypt(s string, key string) (string, error) {
+	shifts, err := vigenereKey(key)
+	if err != nil {
+		return "", err
+	}
+	return mapLetters(s, func(l, i int) int { return l - shifts[i%len(shifts)] }), nil
+}
+
+func modInverse26(a int) (int, bool) {
+	for x := 1; x < 26; x++ {
+		if mod26(a*x) == 1 {
+			return x, true
+		}
+	}
+	return 0, false
+}
+
+func affineEncrypt(s string, a, b int) (string, error) {
+	if _, ok := modInverse26(a); !ok {
+		return "", errInvalidKey
+	}
+	return mapLetters(s, func(l, _ int) int { return a*l + b }), nil
+}
+
+func affineDecrypt(s string, a, b int) (string, error) {
+	inv, ok := modInverse26(a)
+	if !ok {
+		return "", errInvalidKey
+	}
+	return mapLetters(s, func(l, _ int) int { return inv * (l - b) }), nil
+}
+
+// A substitution key is a permutation of the alphabet: key[i] replaces the i-th letter.
+func substitutionKey(key string) ([26]int, error) {
+	perm := [26]int{}
+	seen := [26]bool{}
+	if len(key) != 26 {
+		return perm, errInvalidKey
+	}
+	for i := 0; i < 26; i++ {
+		l, ok := letterIndex(key[i])
+		if !ok || seen[l] {
+			return perm, errInvalidKey
+		}
+		seen[l] = true
+		perm[i] = l
+	}
+	return perm, nil
+}
+
+func substitutionEncrypt(s string, key string) (string, error) {
+	perm, err := substitutionKey(key)
+	if err != nil {
+		return "", err
+	}
+	return mapLetters(s, func(l, _ int) int { return perm[l] }), nil
+}
+
+func substitutionDecrypt(s string, key string) (string, error) {
+	perm, err := substitutionKey(key)
+	if err != nil {
+		return "", err
+	}
+	inverse := [26]int{}
+	for i, p := range perm {
+		inverse[p] = i
+	}
+	return mapLetters(s, func(l, _ int) int { return inverse[l] }), nil
+}
+
+func letterCounts(s string) ([26]int, int) {
+	counts := [26]int{}
+	total := 0
+	for i := 0; i < len(s); i++ {
+		if l, ok := letterIndex(s[i]); ok {
+			counts[l]++
+			total++
+		}
+	}
+	return counts, total
+}
+
+// chiSquared measures how far the letter distribution of s is from English. Lower is better.
+func chiSquared(s string) float64 {
+	counts, total := letterCounts(s)
+	if total == 0 {
+		return math.Inf(1)
+	}
+	chi := 0.0
+	for i, c := range counts {
+		expected := englishFrequencies[i] * float64(total)
+		chi += (float64(c) - expected) * (float64(c) - expected) / expected
+	}
+	return chi
+}
+
+func indexOfCoincidence(s string) float64 {
+	counts, total := letterCounts(s)
+	if total < 2 {
+		return 0
+	}
+	sum := 0
+	for _, c := range counts {
+		sum += c * (c - 1)
+	}
+	return float64(sum) / float64(total*(total-1))
+}
+
+func onlyLetters(s string) string {
+	var b strings.Builder
+	for i := 0; i < len(s); i++ {
+		if l, ok := letterIndex(s[i]); ok {
+			b.WriteByte(byte('A' + l))
+		}
+	}
+	return b.String()
+}
+
+type candidate struct {
+	key       string
+	plaintext string
+	score     float64 // lower is better
+}
+
+func rankCandidates(candidates []candidate, limit int) []candidate {
+	sort.SliceStable(candidates, func(i, j int) bool {
+		if candidates[i].score == candidates[j].score {
+			return candidates[i].key < candidates[j].key
+		}
+		return candidates[i].score < candidates[j].score
+	})
+	if limit > 0 && len(candidates) > limit {
+		candidates = candidates[:limit]
+	}
+	return candidates
+}
+
+func crackCaesar(ciphertext string, limit int) []candidate {
+	candidates := []candidate{}
+	for shift := 0; shift < 26; shift++ {
+		plaintext := caesarDecrypt(ciphertext, shift)
+		candidates = append(candidates, candidate{string(rune('A' + shift)), plaintext, chiSquared(plaintext)})
+	}
+	return rankCandidates(candidates, limit)
+}
+
+func crackAffine(ciphertext string, limit int) []candidate {
+	candidates := []candidate{}
+	for a := 1; a < 26; a++ {
+		if _, ok := modInverse26(a); !ok {
+			continue
+		}
+		for b := 0; b < 26; b++ {
+			plaintext, _ := affineDecrypt(ciphertext, a, b)
+			candidates = append(candidates, candidate{fmt.Sprintf("%d,%d", a, b), plaintext, chiSquared(plaintext)})
+		}
+	}
+	return rankCandidates(candidates, limit)
+}
+
+type keyLengthScore struct {
+	length int
+	score  float64
+}
+
+// kasiski counts, for every candidate key length, how many distances between
+// repeated trigrams it divides.
+func kasiski(ciphertext string, maxLength int) []keyLengthScore {
+	text := onlyLetters(ciphertext)
+	positions := make(map[string][]int)
+	for i := 0; i+3 <= len(text); i++ {
+		positions[text[i:i+3]] = append(positions[text[i:i+3]], i)
+	}
+	counts := make([]int, maxLength+1)
+	for _, p := range positions {
+		for i := 1; i < len(p); i++ {
+			distance := p[i] - p[i-1]
+			for l := 2; l <= maxLength; l++ {
+				if distance%l == 0 {
+					counts[l]++
+				}
+			}
+		}
+	}
+	ret := []keyLengthScore{}
+	for l := 2; l <= maxLength; l++ {
+		ret = append(ret, keyLengthScore{l, float64(counts[l])})
+	}
+	sort.SliceStable(ret, func(i, j int) bool { return ret[i].score > ret[j].score })
+	return ret
+}
+
+// vigenereKeyLengths ranks key lengths by how close the average index of
+// coincidence of the interleaved columns is to English (about 0.066).
+func vigenereKeyLengths(ciphertext string, maxLength int) []keyLengthScore {
+	text := onlyLetters(ciphertext)
+	ret := []keyLengthScore{}
+	for l := 1; l <= maxLength && l <= len(text)/2; l++ {
+		ic := 0.0
+		for _, column := range columns(text, l) {
+			ic += indexOfCoincidence(column)
+		}
+		ret = append(ret, keyLengthScore{l, math.Abs(ic/float64(l) - 0.066)})
+	}
+	// Multiples of the real length can score as well as it does, so ties go to the shorter key.
+	sort.Slice(ret, func(i, j int) bool {
+		if ret[i].score != ret[j].score {
+			return ret[i].score < ret[j].score
+		}
+		return ret[i].length < ret[j].length
+	})
+	return ret
+}
+
+func columns(text string, l int) []string {
+	cols := make([]strings.Builder, l)
+	for i := 0; i < len(text); i++ {
+		cols[i%l].WriteByte(text[i])
+	}
+	ret := []string{}
+	for i := range cols {
+		ret = append(ret, cols[i].String())
+	}
+	return ret
+}
+
+// bestShift picks the Caesar shift whose letter counts correlate best with English.
+// On short columns this is steadier than the chi-squared statistic.
+func bestShift(column string) int {
+	counts, _ := letterCounts(column)
+	best, bestScore := 0, -1.0
+	for shift := 0; shift < 26; shift++ {
+		score := 0.0
+		for i, f := range englishFrequencies {
+			score += f * float64(counts[(i+shift)%26])
+		}
+		if score > bestScore {
+			best, bestScore = shift, score
+		}
+	}
+	return best
+}
+
+func crackVigenere(ciphertext string, maxLength int, limit int) []candidate {
+	text := onlyLetters(ciphertext)
+	candidates := []candidate{}
+	lengths := vigenereKeyLengths(ciphertext, maxLength)
+	if len(lengths) > 3 {
+		lengths = lengths[:3]
+	}
+	for _, kl := range lengths {
+		key := []byte{}
+		for _, column := range columns(text, kl.length) {
+			key = append(key, byte('A'+bestShift(column)))
+		}
+		// Columns are short, so refine each key letter against the whole text.
+		for pass := 0; pass < 2; pass++ {
+			for i := range key {
+				bestLetter, bestFitness := key[i], math.Inf(-1)
+				for l := byte('A'); l <= 'Z'; l++ {
+					key[i] = l
+					plaintext, _ := vigenereDecrypt(text, string(key))
+					if f := trigramFitness(plaintext); f > bestFitness {
+						bestLetter, bestFitness = l, f
+					}
+				}
+				key[i] = bestLetter
+			}
+		}
+		plaintext, _ := vigenereDecrypt(ciphertext, string(key))
+		candidates = append(candidates, candidate{string(key), plaintext, chiSquared(plaintext)})
+	}
+	return rankCandidates(candidates, limit)
+}
+
+var trigramScores map[string]float64
+var trigramFloor float64
+
+// A small English training text for the trigram model that scores candidate plaintexts.
+const englishSample = `It was the best of times, it was the worst of times, it was the age of wisdom,
+it was the age of foolishness, it was the epoch of belief, it was the epoch of incredulity,
+it was the season of light, it was the season of darkness, it was the spring of hope, it was
+the winter of despair, we had everything before us, we had nothing before us, we were all going
+direct to heaven, we were all going direct the other way. In short, the period was so far like
+the present period, that some of its noisiest authorities insisted on its being received, for
+good or for evil, in the superlative degree of comparison only. There were a king with a large
+jaw and a queen with a plain face, on the throne of England; there were a king with a large jaw
+and a queen with a fair face, on the throne of France. In both countries it was clearer than
+crystal to the lords of the State preserves of loaves and fishes, that things in general were
+settled for ever. The objective of this project was to rewrite all the solutions of the problems
+of the book about classic computer science problems, and while doing that there was the learning
+of the algorithms and of the languages themselves, which is the point of the whole exercise.
+Call me Ishmael. Some years ago, never mind how long precisely, having little or no money in my
+purse, and nothing particular to interest me on shore, I thought I would sail about a little and
+see the watery part of the world. It is a way I have of driving off the spleen and regulating the
+circulation. Whenever I find myself growing grim about the mouth; whenever it is a damp, drizzly
+November in my soul; whenever I find myself involuntarily pausing before coffin warehouses, and
+bringing up the rear of every funeral I meet, then I account it high time to get to sea as soon
+as I can. This is my substitute for pistol and ball. With a philosophical flourish Cato throws
+himself upon his sword; I quietly take to the ship. There is nothing surprising in this.`
+
+func loadTrigrams() {
+	if trigramScores != nil {
+		return
+	}
+	text := onlyLetters(englishSample)
+	counts := make(map[string]int)
+	total := 0
+	for i := 0; i+3 <= len(text); i++ {
+		counts[text[i:i+3]]++
+		total++
+	}
+	trigramScores = make(map[string]float64)
+	for q, c := range counts {
+		trigramScores[q] = math.Log10(float64(c) / float64(total))
+	}
+	trigramFloor = math.Log10(0.01 / float64(total))
+}
+
+// trigramFitness is the log-likelihood of text under the trigram model. Higher is better.
+func trigramFitness(text string) float64 {
+	loadTrigrams()
+	fitness := 0.0
+	for i := 0; i+3 <= len(text); i++ {
+		if s, ok := trigramScores[text[i:i+3]]; ok {
+			fitness += s
+		} else {
+			fitness += trigramFloor
+		}
+	}
+	// Mix in single letter frequencies so short texts still move towards English.
+	return fitness - chiSquared(text)/10
+}
+
+func decryptPerm(text string, inverse []byte) string {
+	ret := make([]byte, len(text))
+	for i := 0; i < len(text); i++ {
+		ret[i] = inverse[text[i]-'A']
+	}
+	return string(ret)
+}
+
+// crackSubstitution hill-climbs from random keys by swapping two letters at a time,
+// restarting restarts times. The key returned is the encryption alphabet.
+func crackSubstitution(ciphertext string, restarts int, seed int64, limit int) []candidate {
+	text := onlyLetters(ciphertext)
+	rnd := rand.New(rand.NewSource(seed))
+	best := make(map[string]candidate)
+	for r := 0; r < restarts; r++ {
+		// inverse[c] is the plaintext letter for ciphertext letter c.
+		inverse := []byte("ABCDEFGHIJKLMNOPQRSTUVWXYZ")
+		rnd.Shuffle(26, func(i, j int) { inverse[i], inverse[j] = inverse[j], inverse[i] })
+		score := trigramFitness(decryptPerm(text, inverse))
+		for stale := 0; stale < 1500; stale++ {
+			i, j := rnd.Intn(26), rnd.Intn(26)
+			inverse[i], inverse[j] = inverse[j], inverse[i]
+			newScore := trigramFitness(decryptPerm(text, inverse))
+			if newScore > score {
+				score = newScore
+				stale = 0
+			} else {
+				inverse[i], inverse[j] = inverse[j], inverse[i]
+			}
+		}
+		key := make([]byte, 26)
+		for c, p := range inverse {
+			key[p-'A'] = byte('A' + c)
+		}
+		plaintext, _ := substitutionDecrypt(ciphertext, string(key))
+		// Letters missing from the ciphertext leave the key ambiguous, so keep one key per plaintext.
+		if c, ok := best[plaintext]; !ok || string(key) < c.key {
+			best[plaintext] = candidate{string(key), plaintext, -score}
+		}
+	}
+	candidates := []candidate{}
+	for _, c := range best {
+		candidates = append(candidates, c)
+	}
+	return rankCandidates(candidates, limit)
+}
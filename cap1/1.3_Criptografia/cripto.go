@@ -1,46 +1,149 @@
 package main
 
 import (
+	"bytes"
+	"flag"
 	"fmt"
-	"math/big"
-	"math/rand"
+	"log"
+	"strings"
 )
 
-func generateRandomKey(l int) *big.Int {
-	randomKey := big.NewInt(0)
-	token := make([]byte, l)
-	rand.Read(token)
-	for i := 0; i < l; i++ {
-		randomKey.Lsh(randomKey, 8)
-		randomKey.Or(randomKey, big.NewInt(int64(token[i])))
+func generateRandomKey(l int) []byte {
+	key, err := generateKey(l)
+	if err != nil {
+		log.Fatal(err)
 	}
-	return randomKey
+	return key
 }
 
-func encodeMessage(s string) (*big.Int, *big.Int) {
+// encodeMessage encrypts s with a fresh key, which it records in keys first.
+func encodeMessage(s string, keys *keyLog) ([]byte, []byte, error) {
 	randomKey := generateRandomKey(len(s))
-	encMessage := big.NewInt(0)
-	for i := 0; i < len(s); i++ {
-		encMessage.Lsh(encMessage, 8)
-		encMessage.Or(encMessage, big.NewInt(int64(s[i])))
+	if err := keys.record(randomKey); err != nil {
+		return nil, nil, err
 	}
-	encMessage.Xor(encMessage, randomKey)
-	return encMessage, randomKey
+	encMessage, err := xorBytes([]byte(s), randomKey)
+	if err != nil {
+		return nil, nil, err
+	}
+	return encMessage, randomKey, nil
 }
 
-func decodeMessage(encMessage *big.Int, randomKey *big.Int) string {
-	strMessage := ""
-	message := big.NewInt(0)
-	message.Xor(encMessage, randomKey)
-	for _, val := range message.Bytes() {
-		strMessage += string(val)
+func decodeMessage(encMessage []byte, randomKey []byte) (string, error) {
+	message, err := xorBytes(encMessage, randomKey)
+	if err != nil {
+		return "", err
 	}
-	return strMessage
+	return string(message), nil
+}
+
+func runCommand(args []string, keyLogPath string) error {
+	keys := keyLog{}
+	if err := keys.init(keyLogPath); err != nil {
+		return err
+	}
+	switch {
+	case len(args) == 4 && args[0] == "encrypt":
+		return encryptFile(args[1], args[2], args[3], &keys)
+	case len(args) == 4 && args[0] == "encrypt-with":
+		return encryptWithKey(args[1], args[2], args[3], &keys)
+	case len(args) == 4 && args[0] == "decrypt":
+		return decryptFile(args[1], args[2], args[3])
+	}
+	return fmt.Errorf("usage: encrypt <message> <ciphertext> <key>, encrypt-with <message> <key> <ciphertext>, decrypt <ciphertext> <key> <message>")
 }
 
 func main() {
-	encMessage, randomKey := encodeMessage("ARLima")
+	keyLogPath := flag.String("keylog", "otp_keys.log", "file that remembers the fingerprints of used keys")
+	flag.Parse()
+	if flag.NArg() > 0 {
+		if err := runCommand(flag.Args(), *keyLogPath); err != nil {
+			log.Fatal(err)
+		}
+		return
+	}
+
+	// The demo keeps its key log in memory.
+	keys := keyLog{}
+	if err := keys.init(""); err != nil {
+		log.Fatal(err)
+	}
+	encMessage, randomKey, err := encodeMessage("ARLima", &keys)
+	if err != nil {
+		log.Fatal(err)
+	}
 	fmt.Println(randomKey)
 	fmt.Println(encMessage)
 	fmt.Println(decodeMessage(encMessage, randomKey))
+
+	encMessage, randomKey, err = encodeMessage("\x00\x00São Paulo, ação ✓", &keys)
+	if err != nil {
+		log.Fatal(err)
+	}
+	fmt.Printf("%x\n", encMessage)
+	fmt.Println(decodeMessage(encMessage, randomKey))
+	fmt.Println(decodeMessage(encMessage, randomKey[:3]))
+
+	plaintext := "Cryptography was once the business of kings and generals, and for most of history a cipher that " +
+		"resisted the enemy for a few weeks was considered good enough. Frequency analysis changed that, because " +
+		"every language leaves fingerprints in the letters it uses most often."
+
+	caesar := caesarEncrypt(plaintext, 7)
+	fmt.Println(caesar)
+	for _, c := range crackCaesar(caesar, 3) {
+		fmt.Printf("caesar %s %.1f: %.40s\n", c.key, c.score, c.plaintext)
+	}
+
+	affine, _ := affineEncrypt(plaintext, 5, 8)
+	for _, c := range crackAffine(affine, 3) {
+		fmt.Printf("affine %s %.1f: %.40s\n", c.key, c.score, c.plaintext)
+	}
+
+	vigenere, _ := vigenereEncrypt(plaintext, "LEMON")
+	fmt.Println("kasiski:", kasiski(vigenere, 10)[:3])
+	fmt.Println("index of coincidence:", vigenereKeyLengths(vigenere, 10)[:3])
+	for _, c := range crackVigenere(vigenere, 10, 3) {
+		fmt.Printf("vigenere %s %.1f: %.40s\n", c.key, c.score, c.plaintext)
+	}
+
+	substitution, _ := substitutionEncrypt(plaintext, "QWERTYUIOPASDFGHJKLZXCVBNM")
+	for _, c := range crackSubstitution(substitution, 50, 1, 3) {
+		fmt.Printf("substitution %s %.1f: %.40s\n", c.key, c.score, c.plaintext)
+	}
+
+	secret := []byte("\x00\x00" + strings.Repeat("Problemas Clássicos ", 5))
+	xorShares, err := splitXOR(secret, 4)
+	if err != nil {
+		log.Fatal(err)
+	}
+	recovered, err := combineShares(xorShares)
+	fmt.Println("xor 4-of-4:", bytes.Equal(recovered, secret), err)
+	_, err = combineShares(xorShares[:3])
+	fmt.Println("xor 3-of-4:", err)
+
+	shamirShares, err := splitShamir(secret, 3, 5)
+	if err != nil {
+		log.Fatal(err)
+	}
+	texts := []string{}
+	for _, s := range shamirShares {
+		texts = append(texts, s.String())
+	}
+	fmt.Printf("%.60s...\n", texts[0])
+	parsed := []share{}
+	for _, t := range []string{texts[4], texts[1], texts[2]} {
+		s, err := parseShare(t)
+		if err != nil {
+			log.Fatal(err)
+		}
+		parsed = append(parsed, s)
+	}
+	recovered, err = combineShares(parsed)
+	fmt.Println("shamir 3-of-5:", bytes.Equal(recovered, secret), err)
+	_, err = combineShares(parsed[:2])
+	fmt.Println("shamir 2-of-5:", err)
+	_, err = combineShares(append(parsed[:2], shamirShares[0], xorShares[0]))
+	fmt.Println("mixed shares:", err)
+	_, err = parseShare(strings.Replace(texts[0], ":3:5:1:", ":3:5:2:", 1))
+	fmt.Println("tampered share:", err)
 }
@@ -0,0 +1,269 @@
+package main
+
+import (
+	"bufio"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+)
+
+const (
+	otpBufferSize  = 1 << 16
+	fingerprintLen = 64 // key bytes hashed into a fingerprint
+	keyLogHeader   = "otp-key-log v2 salt "
+	saltLen        = 32
+)
+
+var (
+	errKeyTooShort = errors.New("key is shorter than the message")
+	errKeyReused   = errors.New("key has already been used")
+	errKeyLog      = errors.New("unsupported key log")
+)
+
+func generateKey(l int) ([]byte, error) {
+	key := make([]byte, l)
+	if _, err := io.ReadFull(rand.Reader, key); err != nil {
+		return nil, err
+	}
+	return key, nil
+}
+
+func xorBytes(message []byte, key []byte) ([]byte, error) {
+	if len(key) < len(message) {
+		return nil, errKeyTooShort
+	}
+	ret := make([]byte, len(message))
+	for i := range message {
+		ret[i] = message[i] ^ key[i]
+	}
+	return ret, nil
+}
+
+// keyFingerprint is an HMAC of the first fingerprintLen bytes of a key under the salt
+// of the log. Using the same key twice gives the same fingerprint; so does any key with
+// the same first fingerprintLen bytes, while a key shorter than that only matches an
+// identical key. The salt stops precomputed tables, but short keys remain guessable by
+// whoever holds the log, so keep it private.
+func keyFingerprint(salt []byte, key []byte) string {
+	if len(key) > fingerprintLen {
+		key = key[:fingerprintLen]
+	}
+	mac := hmac.New(sha256.New, salt)
+	mac.Write(key)
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+// keyLog remembers the fingerprints of the keys used so far. The first line of the file
+// holds its random salt. A log without a path lives only in memory.
+type keyLog struct {
+	path         string
+	salt         []byte
+	fingerprints map[string]bool
+}
+
+func (k *keyLog) init(path string) error {
+	k.path = path
+	k.salt = nil
+	k.fingerprints = make(map[string]bool)
+	if path == "" {
+		return nil
+	}
+	f, err := os.Open(path)
+	if os.IsNotExist(err) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	scanner := bufio.NewScanner(f)
+	if scanner.Scan() {
+		header := scanner.Text()
+		if !strings.HasPrefix(header, keyLogHeader) {
+			return fmt.Errorf("%w %s: no salt header, remove the old log", errKeyLog, path)
+		}
+		if k.salt, err = hex.DecodeString(header[len(keyLogHeader):]); err != nil || len(k.salt) != saltLen {
+			return fmt.Errorf("%w %s: bad salt", errKeyLog, path)
+		}
+	}
+	for scanner.Scan() {
+		if line := strings.TrimSpace(scanner.Text()); line != "" {
+			k.fingerprints[line] = true
+		}
+	}
+	return scanner.Err()
+}
+
+// record fails with errKeyReused if the key was logged before, otherwise it appends it
+// to the log, creating the log and its salt on first use.
+func (k *keyLog) record(key []byte) error {
+	if k.salt == nil {
+		salt, err := generateKey(saltLen)
+		if err != nil {
+			return err
+		}
+		if err := k.append(keyLogHeader + hex.EncodeToString(salt)); err != nil {
+			return err
+		}
+		k.salt = salt
+	}
+	fp := keyFingerprint(k.salt, key)
+	if k.fingerprints[fp] {
+		return fmt.Errorf("%w (fingerprint %s)", errKeyReused, fp[:16])
+	}
+	if err := k.append(fp); err != nil {
+		return err
+	}
+	k.fingerprints[fp] = true
+	return nil
+}
+
+func (k *keyLog) append(line string) error {
+	if k.path == "" {
+		return nil
+	}
+	f, err := os.OpenFile(k.path, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0600)
+	if err != nil {
+		return err
+	}
+	if _, err := fmt.Fprintln(f, line); err != nil {
+		f.Close()
+		return err
+	}
+	return f.Close()
+}
+
+// xorStream writes message XOR key to out. It fails with errKeyTooShort if the key
+// runs out before the message. If first is not nil it is called with the first
+// key block before anything is written.
+func xorStream(out io.Writer, message io.Reader, key io.Reader, first func([]byte) error) error {
+	msgBuf := make([]byte, otpBufferSize)
+	keyBuf := make([]byte, otpBufferSize)
+	for {
+		n, err := io.ReadFull(message, msgBuf)
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil && err != io.ErrUnexpectedEOF {
+			return err
+		}
+		if _, kerr := io.ReadFull(key, keyBuf[:n]); kerr == io.EOF || kerr == io.ErrUnexpectedEOF {
+			return errKeyTooShort
+		} else if kerr != nil {
+			return kerr
+		}
+		if first != nil {
+			if ferr := first(keyBuf[:n]); ferr != nil {
+				return ferr
+			}
+			first = nil
+		}
+		for i := 0; i < n; i++ {
+			msgBuf[i] ^= keyBuf[i]
+		}
+		if _, werr := out.Write(msgBuf[:n]); werr != nil {
+			return werr
+		}
+		if err == io.ErrUnexpectedEOF {
+			return nil
+		}
+	}
+}
+
+// encryptFile encrypts in with a fresh key from crypto/rand, writing the
+// ciphertext to out and the key to keyOut.
+func encryptFile(in, out, keyOut string, log *keyLog) error {
+	src, err := os.Open(in)
+	if err != nil {
+		return err
+	}
+	defer src.Close()
+	keyFile, err := os.OpenFile(keyOut, os.O_CREATE|os.O_EXCL|os.O_WRONLY, 0600)
+	if err != nil {
+		return err
+	}
+	defer keyFile.Close()
+	dst, err := os.Create(out)
+	if err != nil {
+		return err
+	}
+	defer dst.Close()
+
+	keyWriter := bufio.NewWriter(keyFile)
+	key := io.TeeReader(rand.Reader, keyWriter)
+	dstWriter := bufio.NewWriter(dst)
+	if err := xorStream(dstWriter, src, key, log.record); err != nil {
+		dst.Close()
+		keyFile.Close()
+		os.Remove(out)
+		os.Remove(keyOut)
+		return err
+	}
+	if err := keyWriter.Flush(); err != nil {
+		return err
+	}
+	if err := dstWriter.Flush(); err != nil {
+		return err
+	}
+	if err := keyFile.Close(); err != nil {
+		return err
+	}
+	return dst.Close()
+}
+
+// encryptWithKey encrypts in with an existing key file. The key must be at least as
+// long as the message and must not appear in the key log.
+func encryptWithKey(in, keyIn, out string, log *keyLog) error {
+	return xorFiles(in, keyIn, out, log.record)
+}
+
+func decryptFile(in, keyIn, out string) error {
+	return xorFiles(in, keyIn, out, nil)
+}
+
+func xorFiles(in, keyIn, out string, first func([]byte) error) error {
+	src, err := os.Open(in)
+	if err != nil {
+		return err
+	}
+	defer src.Close()
+	keyFile, err := os.Open(keyIn)
+	if err != nil {
+		return err
+	}
+	defer keyFile.Close()
+
+	srcInfo, err := src.Stat()
+	if err != nil {
+		return err
+	}
+	keyInfo, err := keyFile.Stat()
+	if err != nil {
+		return err
+	}
+	if keyInfo.Mode().IsRegular() && srcInfo.Mode().IsRegular() && keyInfo.Size() < srcInfo.Size() {
+		return errKeyTooShort
+	}
+
+	dst, err := os.Create(out)
+	if err != nil {
+		return err
+	}
+	defer dst.Close()
+	dstWriter := bufio.NewWriter(dst)
+	if err := xorStream(dstWriter, bufio.NewReader(src), bufio.NewReader(keyFile), first); err != nil {
+		dst.Close()
+		os.Remove(out)
+		return err
+	}
+	if err := dstWriter.Flush(); err != nil {
+		return err
+	}
+	return dst.Close()
+}
@@ -0,0 +1,267 @@
+package main
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"hash/crc32"
+	"math/big"
+	"strconv"
+	"strings"
+)
+
+const (
+	schemeXOR    = "xor"
+	schemeShamir = "shamir"
+	shareVersion = "ss1"
+	setIDLen     = 8
+	shamirBlock  = 64 // secret bytes per polynomial, below the 521-bit prime
+	shamirWidth  = 66 // bytes needed to write a field element
+)
+
+var (
+	errNotEnoughShares  = errors.New("not enough shares")
+	errMismatchedShares = errors.New("shares do not belong to the same secret")
+	errDuplicateShare   = errors.New("duplicate share")
+	errShareChecksum    = errors.New("share checksum mismatch")
+	errShareFormat      = errors.New("malformed share")
+	errShareParameters  = errors.New("invalid sharing parameters")
+)
+
+// The Mersenne prime 2^521 - 1.
+var shamirPrime = new(big.Int).Sub(new(big.Int).Lsh(big.NewInt(1), 521), big.NewInt(1))
+
+type share struct {
+	scheme    string
+	setID     string
+	threshold int
+	total     int
+	index     int
+	length    int // length of the secret in bytes
+	data      []byte
+}
+
+func newSetID() (string, error) {
+	id, err := generateKey(setIDLen)
+	if err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(id), nil
+}
+
+// splitXOR generalizes encodeMessage: n-1 shares are random pads and the last one
+// is the secret XOR all of them, so every share is needed to recover it.
+func splitXOR(secret []byte, n int) ([]share, error) {
+	if n < 2 {
+		return nil, errShareParameters
+	}
+	setID, err := newSetID()
+	if err != nil {
+		return nil, err
+	}
+	shares := []share{}
+	last := append([]byte{}, secret...)
+	for i := 1; i < n; i++ {
+		pad, err := generateKey(len(secret))
+		if err != nil {
+			return nil, err
+		}
+		last, _ = xorBytes(last, pad)
+		shares = append(shares, share{schemeXOR, setID, n, n, i, len(secret), pad})
+	}
+	shares = append(shares, share{schemeXOR, setID, n, n, n, len(secret), last})
+	return shares, nil
+}
+
+// splitShamir splits secret so that any k of the n shares recover it. Every 64-byte
+// block of the secret is the constant term of its own random polynomial of degree k-1.
+func splitShamir(secret []byte, k, n int) ([]share, error) {
+	if k < 2 || n < k || n > 255 {
+		return nil, errShareParameters
+	}
+	setID, err := newSetID()
+	if err != nil {
+		return nil, err
+	}
+	shares := []share{}
+	for i := 1; i <= n; i++ {
+		shares = append(shares, share{schemeShamir, setID, k, n, i, len(secret), []byte{}})
+	}
+	for start := 0; start < len(secret); start += shamirBlock {
+		end := start + shamirBlock
+		if end > len(secret) {
+			end = len(secret)
+		}
+		coefficients := []*big.Int{new(big.Int).SetBytes(secret[start:end])}
+		for c := 1; c < k; c++ {
+			r, err := rand.Int(rand.Reader, shamirPrime)
+			if err != nil {
+				return nil, err
+			}
+			coefficients = append(coefficients, r)
+		}
+		for i := range shares {
+			y := evalPolynomial(coefficients, big.NewInt(int64(shares[i].index)))
+			shares[i].data = append(shares[i].data, y.FillBytes(make([]byte, shamirWidth))...)
+		}
+	}
+	return shares, nil
+}
+
+func evalPolynomial(coefficients []*big.Int, x *big.Int) *big.Int {
+	y := new(big.Int)
+	for i := len(coefficients) - 1; i >= 0; i-- {
+		y.Mul(y, x)
+		y.Add(y, coefficients[i])
+		y.Mod(y, shamirPrime)
+	}
+	return y
+}
+
+// lagrangeAtZero interpolates the polynomial through (xs[i], ys[i]) and evaluates it at 0.
+func lagrangeAtZero(xs []*big.Int, ys []*big.Int) *big.Int {
+	secret := new(big.Int)
+	for i := range xs {
+		numerator := big.NewInt(1)
+		denominator := big.NewInt(1)
+		for j := range xs {
+			if i == j {
+				continue
+			}
+			numerator.Mul(numerator, new(big.Int).Neg(xs[j]))
+			numerator.Mod(numerator, shamirPrime)
+			denominator.Mul(denominator, new(big.Int).Sub(xs[i], xs[j]))
+			denominator.Mod(denominator, shamirPrime)
+		}
+		term := new(big.Int).Mul(ys[i], numerator)
+		term.Mul(term, new(big.Int).ModInverse(denominator, shamirPrime))
+		secret.Add(secret, term)
+		secret.Mod(secret, shamirPrime)
+	}
+	return secret
+}
+
+func checkShares(shares []share) error {
+	if len(shares) == 0 {
+		return errNotEnoughShares
+	}
+	first := shares[0]
+	seen := make(map[int]bool)
+	for _, s := range shares {
+		if s.scheme != first.scheme || s.setID != first.setID || s.threshold != first.threshold ||
+			s.total != first.total || s.length != first.length || len(s.data) != len(first.data) {
+			return fmt.Errorf("%w: share %d", errMismatchedShares, s.index)
+		}
+		if s.index < 1 || s.index > s.total {
+			return fmt.Errorf("%w: index %d out of range", errShareFormat, s.index)
+		}
+		if seen[s.index] {
+			return fmt.Errorf("%w: index %d", errDuplicateShare, s.index)
+		}
+		seen[s.index] = true
+	}
+	if first.threshold < 1 || first.threshold > first.total {
+		return fmt.Errorf("%w: threshold %d of %d shares", errShareFormat, first.threshold, first.total)
+	}
+	if len(shares) < first.threshold {
+		return fmt.Errorf("%w: have %d, need %d", errNotEnoughShares, len(shares), first.threshold)
+	}
+	return nil
+}
+
+func combineShares(shares []share) ([]byte, error) {
+	if err := checkShares(shares); err != nil {
+		return nil, err
+	}
+	switch shares[0].scheme {
+	case schemeXOR:
+		secret := make([]byte, shares[0].length)
+		for _, s := range shares {
+			if len(s.data) != s.length {
+				return nil, fmt.Errorf("%w: share %d has %d bytes for a %d byte secret", errShareFormat, s.index, len(s.data), s.length)
+			}
+			var err error
+			if secret, err = xorBytes(secret, s.data); err != nil {
+				return nil, err
+			}
+		}
+		return secret, nil
+	case schemeShamir:
+		return combineShamir(shares)
+	}
+	return nil, fmt.Errorf("%w: unknown scheme %q", errShareFormat, shares[0].scheme)
+}
+
+func combineShamir(shares []share) ([]byte, error) {
+	length := shares[0].length
+	blocks := (length + shamirBlock - 1) / shamirBlock
+	if len(shares[0].data) != blocks*shamirWidth {
+		return nil, errShareFormat
+	}
+	shares = shares[:shares[0].threshold]
+	xs := []*big.Int{}
+	for _, s := range shares {
+		xs = append(xs, big.NewInt(int64(s.index)))
+	}
+	secret := []byte{}
+	for b := 0; b < blocks; b++ {
+		ys := []*big.Int{}
+		for _, s := range shares {
+			ys = append(ys, new(big.Int).SetBytes(s.data[b*shamirWidth:(b+1)*shamirWidth]))
+		}
+		size := shamirBlock
+		if rest := length - b*shamirBlock; rest < size {
+			size = rest
+		}
+		value := lagrangeAtZero(xs, ys)
+		if value.BitLen() > size*8 {
+			return nil, fmt.Errorf("%w: shares are inconsistent", errMismatchedShares)
+		}
+		secret = append(secret, value.FillBytes(make([]byte, size))...)
+	}
+	return secret, nil
+}
+
+// String writes a share as
+// ss1:<scheme>:<set id>:<threshold>:<total>:<index>:<length>:<hex data>:<crc32>
+// where the CRC-32 covers everything before the last colon.
+func (s share) String() string {
+	body := strings.Join([]string{shareVersion, s.scheme, s.setID, strconv.Itoa(s.threshold), strconv.Itoa(s.total),
+		strconv.Itoa(s.index), strconv.Itoa(s.length), hex.EncodeToString(s.data)}, ":")
+	return fmt.Sprintf("%s:%08x", body, crc32.ChecksumIEEE([]byte(body)))
+}
+
+func parseShare(text string) (share, error) {
+	text = strings.TrimSpace(text)
+	cut := strings.LastIndex(text, ":")
+	if cut < 0 {
+		return share{}, errShareFormat
+	}
+	body := text[:cut]
+	if fmt.Sprintf("%08x", crc32.ChecksumIEEE([]byte(body))) != text[cut+1:] {
+		return share{}, errShareChecksum
+	}
+	fields := strings.Split(body, ":")
+	if len(fields) != 8 || fields[0] != shareVersion {
+		return share{}, errShareFormat
+	}
+	s := share{scheme: fields[1], setID: fields[2]}
+	numbers := []*int{&s.threshold, &s.total, &s.index, &s.length}
+	for i, n := range numbers {
+		v, err := strconv.Atoi(fields[3+i])
+		if err != nil || v < 0 {
+			return share{}, errShareFormat
+		}
+		*n = v
+	}
+	if s.threshold < 1 || s.threshold > s.total {
+		return share{}, fmt.Errorf("%w: threshold %d of %d shares", errShareFormat, s.threshold, s.total)
+	}
+	data, err := hex.DecodeString(fields[7])
+	if err != nil {
+		return share{}, errShareFormat
+	}
+	s.data = data
+	return s, nil
+}
@@ -0,0 +1,205 @@
+package main
+
+import (
+	"fmt"
+	"math"
+	"math/big"
+	"strings"
+	"time"
+)
+
+const piGuardDigits = 10
+
+// The first 100 decimals, used to check the reference value itself.
+const piFirstDigits = "3.1415926535897932384626433832795028841971693993751058209749445923078164062862089986280348253421170679"
+
+func pow10(n int) *big.Int {
+	return new(big.Int).Exp(big.NewInt(10), big.NewInt(int64(n)), nil)
+}
+
+// fixedToString formats value / 10^scale with digits decimals, truncating the rest.
+func fixedToString(value *big.Int, scale int, digits int) string {
+	s := value.String()
+	if len(s) <= scale {
+		s = strings.Repeat("0", scale-len(s)+1) + s
+	}
+	point := len(s) - scale
+	return s[:point] + "." + s[point:point+digits]
+}
+
+// arccot computes unity * arccot(x) with the Taylor series of arctan(1/x).
+func arccot(x int64, unity *big.Int) (*big.Int, int) {
+	sum := new(big.Int).Div(unity, big.NewInt(x))
+	power := new(big.Int).Set(sum)
+	x2 := big.NewInt(x * x)
+	term := new(big.Int)
+	n := int64(1)
+	terms := 1
+	for {
+		power.Div(power, x2)
+		n += 2
+		term.Div(power, big.NewInt(n))
+		if term.Sign() == 0 {
+			break
+		}
+		if terms%2 == 1 {
+			sum.Sub(sum, term)
+		} else {
+			sum.Add(sum, term)
+		}
+		terms++
+	}
+	return sum, terms
+}
+
+// piMachin uses pi = 16 arctan(1/5) - 4 arctan(1/239).
+func piMachin(digits int) (string, int) {
+	scale := digits + piGuardDigits
+	unity := pow10(scale)
+	a, termsA := arccot(5, unity)
+	b, termsB := arccot(239, unity)
+	pi := new(big.Int).Mul(a, big.NewInt(16))
+	pi.Sub(pi, new(big.Int).Mul(b, big.NewInt(4)))
+	return fixedToString(pi, scale, digits), termsA + termsB
+}
+
+// 640320^3 / 24
+var chudnovskyC3Over24 = big.NewInt(10939058860032000)
+
+// chudnovskySplit returns P(a,b), Q(a,b) and T(a,b) for the terms a..b-1.
+func chudnovskySplit(a, b int64) (*big.Int, *big.Int, *big.Int) {
+	if b-a == 1 {
+		var p, q *big.Int
+		if a == 0 {
+			p = big.NewInt(1)
+			q = big.NewInt(1)
+		} else {
+			p = big.NewInt(6*a - 5)
+			p.Mul(p, big.NewInt(2*a-1))
+			p.Mul(p, big.NewInt(6*a-1))
+			q = big.NewInt(a)
+			q.Mul(q, q).Mul(q, big.NewInt(a))
+			q.Mul(q, chudnovskyC3Over24)
+		}
+		t := new(big.Int).Mul(p, big.NewInt(13591409+545140134*a))
+		if a%2 == 1 {
+			t.Neg(t)
+		}
+		return p, q, t
+	}
+	m := (a + b) / 2
+	pam, qam, tam := chudnovskySplit(a, m)
+	pmb, qmb, tmb := chudnovskySplit(m, b)
+	p := new(big.Int).Mul(pam, pmb)
+	q := new(big.Int).Mul(qam, qmb)
+	t := new(big.Int).Mul(tam, qmb)
+	t.Add(t, new(big.Int).Mul(pam, tmb))
+	return p, q, t
+}
+
+// piChudnovsky sums the Chudnovsky series with binary splitting. Each term adds about 14.18 digits.
+func piChudnovsky(digits int) (string, int) {
+	scale := digits + piGuardDigits
+	terms := int64(float64(scale)/14.181647462725477) + 1
+	_, q, t := chudnovskySplit(0, terms)
+	unity := pow10(scale)
+	sqrtC := new(big.Int).Mul(big.NewInt(10005), unity)
+	sqrtC.Mul(sqrtC, unity)
+	sqrtC.Sqrt(sqrtC)
+	pi := new(big.Int).Mul(q, big.NewInt(426880))
+	pi.Mul(pi, sqrtC)
+	pi.Quo(pi, t)
+	return fixedToString(pi, scale, digits), int(terms)
+}
+
+// piGaussLegendre doubles the number of correct digits on every iteration.
+func piGaussLegendre(digits int) (string, int) {
+	prec := uint(float64(digits+piGuardDigits)*math.Log2(10)) + 64
+	newFloat := func(x float64) *big.Float { return new(big.Float).SetPrec(prec).SetFloat64(x) }
+	a := newFloat(1)
+	b := new(big.Float).SetPrec(prec).Sqrt(newFloat(0.5))
+	t := newFloat(0.25)
+	p := newFloat(1)
+	epsilon := new(big.Float).SetPrec(prec).SetMantExp(newFloat(1), -int(prec)+32)
+	iterations := 0
+	for {
+		iterations++
+		an := newFloat(0).Add(a, b)
+		an.Quo(an, newFloat(2))
+		b.Sqrt(newFloat(0).Mul(a, b))
+		d := newFloat(0).Sub(a, an)
+		d.Mul(d, d)
+		t.Sub(t, d.Mul(d, p))
+		a = an
+		p.Add(p, p)
+		if newFloat(0).Abs(newFloat(0).Sub(a, b)).Cmp(epsilon) <= 0 {
+			break
+		}
+	}
+	pi := newFloat(0).Add(a, b)
+	pi.Mul(pi, pi)
+	pi.Quo(pi, t.Mul(t, newFloat(4)))
+	s := pi.Text('f', digits+piGuardDigits)
+	return s[:2+digits], iterations
+}
+
+func piLeibniz(digits int) (string, int) {
+	terms := 100000
+	return fmt.Sprintf("%.*f", digits, calcPI(terms)), terms
+}
+
+type piMethod struct {
+	name    string
+	compute func(digits int) (string, int)
+}
+
+type piReport struct {
+	method    string
+	digits    int
+	terms     int
+	elapsed   time.Duration
+	confirmed int
+}
+
+func (r piReport) String() string {
+	return fmt.Sprintf("%-15s %8d terms %12v %8d/%d digits confirmed", r.method, r.terms, r.elapsed.Round(time.Microsecond), r.confirmed, r.digits)
+}
+
+// confirmedDigits counts the decimals of value that agree with reference.
+func confirmedDigits(value, reference string) int {
+	n := 0
+	for i := 2; i < len(value) && i < len(reference) && value[i] == reference[i]; i++ {
+		n++
+	}
+	return n
+}
+
+// convergenceReport runs every method for digits decimals and checks them against a
+// reference computed with extra digits by both Chudnovsky and Machin, kept only as far
+// as the two independent series agree.
+func convergenceReport(digits int) ([]piReport, error) {
+	chudnovsky, _ := piChudnovsky(digits + 20)
+	machin, _ := piMachin(digits + 20)
+	agreed := confirmedDigits(chudnovsky, machin)
+	if agreed < digits {
+		return nil, fmt.Errorf("the Chudnovsky and Machin references agree on only %d of %d digits", agreed, digits)
+	}
+	reference := chudnovsky[:2+agreed]
+	if n := min(len(reference), len(piFirstDigits)); reference[:n] != piFirstDigits[:n] {
+		return nil, fmt.Errorf("reference value does not match the known digits of pi")
+	}
+	methods := []piMethod{
+		{"Leibniz", piLeibniz},
+		{"Machin", piMachin},
+		{"Chudnovsky", piChudnovsky},
+		{"Gauss-Legendre", piGaussLegendre},
+	}
+	reports := []piReport{}
+	for _, m := range methods {
+		start := time.Now()
+		value, terms := m.compute(digits)
+		elapsed := time.Since(start)
+		reports = append(reports, piReport{m.name, digits, terms, elapsed, confirmedDigits(value, reference)})
+	}
+	return reports, nil
+}
@@ -0,0 +1,103 @@
+package main
+
+import (
+	"fmt"
+	"log"
+	"math"
+	"math/rand"
+	"sync"
+)
+
+const z95 = 1.959963984540054
+
+type monteCarloConfig struct {
+	workers    int
+	seed       int64
+	batchSize  int     // samples drawn by each worker per round
+	precision  float64 // stop when the 95% Wilson interval half-width is at most this
+	maxSamples int64
+}
+
+type monteCarloEstimate struct {
+	samples   int64
+	hits      int64
+	estimate  float64
+	stdErr    float64
+	low       float64
+	high      float64
+	converged bool
+}
+
+func (e monteCarloEstimate) String() string {
+	return fmt.Sprintf("%12d samples: pi ~ %.6f, std error %.6f, 95%% CI [%.6f, %.6f]", e.samples, e.estimate, e.stdErr, e.low, e.high)
+}
+
+// newEstimate scales the Wilson score interval of the hit rate to pi. Unlike the normal
+// interval it does not collapse to a point when none or all of the samples hit.
+func newEstimate(samples, hits int64) monteCarloEstimate {
+	n := float64(samples)
+	p := float64(hits) / n
+	stdErr := 4 * math.Sqrt(p*(1-p)/n)
+	z2 := z95 * z95
+	center := (p + z2/(2*n)) / (1 + z2/n)
+	half := z95 / (1 + z2/n) * math.Sqrt(p*(1-p)/n+z2/(4*n*n))
+	return monteCarloEstimate{samples, hits, 4 * p, stdErr, math.Max(0, 4*(center-half)), math.Min(4, 4*(center+half)), false}
+}
+
+// workerSeed spreads the run seed into one independent seed per worker (SplitMix64).
+func workerSeed(seed int64, worker int) int64 {
+	z := uint64(seed) + uint64(worker+1)*0x9e3779b97f4a7c15
+	z = (z ^ (z >> 30)) * 0xbf58476d1ce4e5b9
+	z = (z ^ (z >> 27)) * 0x94d049bb133111eb
+	return int64(z ^ (z >> 31))
+}
+
+// monteCarloPI samples points in the unit square in rounds: every worker draws batchSize
+// points with its own generator, then the counts are merged. Since the rounds are fixed,
+// the same seed and number of workers always give the same result.
+func monteCarloPI(config monteCarloConfig, progress func(monteCarloEstimate)) monteCarloEstimate {
+	if config.workers < 1 || config.batchSize < 1 || config.maxSamples < 1 {
+		log.Fatal("workers, batchSize and maxSamples must be >= 1")
+	}
+	generators := []*rand.Rand{}
+	for w := 0; w < config.workers; w++ {
+		generators = append(generators, rand.New(rand.NewSource(workerSeed(config.seed, w))))
+	}
+	hitsPerWorker := make([]int64, config.workers)
+
+	var samples, hits int64
+	estimate := monteCarloEstimate{}
+	for samples < config.maxSamples {
+		var wg sync.WaitGroup
+		for w := range generators {
+			wg.Add(1)
+			go func(w int) {
+				defer wg.Done()
+				rnd := generators[w]
+				h := int64(0)
+				for i := 0; i < config.batchSize; i++ {
+					x, y := rnd.Float64(), rnd.Float64()
+					if x*x+y*y <= 1 {
+						h++
+					}
+				}
+				hitsPerWorker[w] = h
+			}(w)
+		}
+		wg.Wait()
+		for _, h := range hitsPerWorker {
+			hits += h
+		}
+		samples += int64(config.workers * config.batchSize)
+
+		estimate = newEstimate(samples, hits)
+		if progress != nil {
+			progress(estimate)
+		}
+		if config.precision > 0 && (estimate.high-estimate.low)/2 <= config.precision {
+			estimate.converged = true
+			break
+		}
+	}
+	return estimate
+}
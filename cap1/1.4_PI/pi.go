@@ -2,6 +2,10 @@ package main
 
 import (
 	"fmt"
+	"log"
+	"math"
+	"os"
+	"strconv"
 )
 
 func calcPI(terms int) float64 {
@@ -19,4 +23,33 @@ func calcPI(terms int) float64 {
 
 func main() {
 	fmt.Println(calcPI(100000))
+
+	digits := 1000
+	if len(os.Args) > 1 {
+		n, err := strconv.Atoi(os.Args[1])
+		if err != nil || n < 1 {
+			log.Fatal("usage: pi [digits]")
+		}
+		digits = n
+	}
+	value, _ := piChudnovsky(digits)
+	fmt.Printf("%.72s...\n", value)
+	reports, err := convergenceReport(digits)
+	if err != nil {
+		log.Fatal(err)
+	}
+	for _, r := range reports {
+		fmt.Println(r)
+	}
+
+	config := monteCarloConfig{workers: 4, seed: 2020, batchSize: 250000, precision: 0.001, maxSamples: 100000000}
+	round := 0
+	result := monteCarloPI(config, func(e monteCarloEstimate) {
+		if round%10 == 0 {
+			fmt.Println(e)
+		}
+		round++
+	})
+	fmt.Println(result)
+	fmt.Println("converged:", result.converged, "error:", math.Abs(result.estimate-math.Pi))
 }
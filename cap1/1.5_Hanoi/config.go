@@ -0,0 +1,210 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+)
+
+var errIllegalConfig = errors.New("illegal configuration")
+
+// hanoiConfig holds the peg of every disk, smallest disk first. Any assignment of disks
+// to pegs is a legal position, because each peg stacks its disks largest first.
+type hanoiConfig []int
+
+// configFromTowers checks that every disk 1..n appears once and never on top of a smaller one.
+func configFromTowers(t towers) (hanoiConfig, error) {
+	n := 0
+	for _, p := range t.pegs {
+		n += len(p.container)
+	}
+	config := make(hanoiConfig, n)
+	seen := make([]bool, n+1)
+	for p, peg := range t.pegs {
+		for level, d := range peg.container {
+			if d < 1 || d > n {
+				return nil, fmt.Errorf("%w: disk %d on peg %s is out of range 1..%d", errIllegalConfig, d, pegName(p), n)
+			}
+			if seen[d] {
+				return nil, fmt.Errorf("%w: disk %d appears twice", errIllegalConfig, d)
+			}
+			if level > 0 && peg.container[level-1] < d {
+				return nil, fmt.Errorf("%w: disk %d on top of smaller disk %d on peg %s", errIllegalConfig, d, peg.container[level-1], pegName(p))
+			}
+			seen[d] = true
+			config[d-1] = p
+		}
+	}
+	return config, nil
+}
+
+func (c hanoiConfig) towers(pegs int) towers {
+	t := towers{pegs: make([]stack, pegs)}
+	for d := len(c); d >= 1; d-- {
+		t.pegs[c[d-1]].push(d)
+	}
+	return t
+}
+
+func (c hanoiConfig) validate(other hanoiConfig) error {
+	if len(c) != len(other) {
+		return fmt.Errorf("%w: %d disks against %d", errIllegalConfig, len(c), len(other))
+	}
+	if len(c) > 63 {
+		return fmt.Errorf("%w: at most 63 disks", errIllegalConfig)
+	}
+	for _, config := range []hanoiConfig{c, other} {
+		for d, p := range config {
+			if p < 0 || p > 2 {
+				return fmt.Errorf("%w: disk %d on peg %d, only 3 pegs are supported", errIllegalConfig, d+1, p)
+			}
+		}
+	}
+	return nil
+}
+
+// toPegCost is the optimal number of moves to gather disks 1..m of c on peg p.
+func toPegCost(c hanoiConfig, m int, p int) uint64 {
+	cost := uint64(0)
+	for ; m > 0; m-- {
+		if c[m-1] != p {
+			// Disk m moves once; the smaller ones first go to the third peg and then follow it.
+			cost += 1 + (uint64(1)<<uint(m-1) - 1)
+			p = 3 - c[m-1] - p
+		}
+	}
+	return cost
+}
+
+// largestMoveCosts returns the cost of moving disk m once (through the smaller disks parked on
+// the third peg) and twice (parking them on the target first, then back on the source).
+func largestMoveCosts(a, b hanoiConfig, m int) (uint64, uint64) {
+	src, dst := a[m-1], b[m-1]
+	other := 3 - src - dst
+	once := toPegCost(a, m-1, other) + 1 + toPegCost(b, m-1, other)
+	twice := toPegCost(a, m-1, dst) + 1 + (uint64(1)<<uint(m-1) - 1) + 1 + toPegCost(b, m-1, src)
+	return once, twice
+}
+
+// configDistance is the minimum number of moves between two configurations on 3 pegs.
+func configDistance(a, b hanoiConfig) (uint64, error) {
+	if err := a.validate(b); err != nil {
+		return 0, err
+	}
+	m := len(a)
+	for m > 0 && a[m-1] == b[m-1] {
+		m--
+	}
+	if m == 0 {
+		return 0, nil
+	}
+	once, twice := largestMoveCosts(a, b, m)
+	if twice < once {
+		return twice, nil
+	}
+	return once, nil
+}
+
+// moveToPeg gathers disks 1..m of state on peg p, updating state.
+func moveToPeg(state hanoiConfig, m int, p int, visit moveVisitor) {
+	if m == 0 {
+		return
+	}
+	if state[m-1] == p {
+		moveToPeg(state, m-1, p, visit)
+		return
+	}
+	from := state[m-1]
+	moveToPeg(state, m-1, 3-from-p, visit)
+	state[m-1] = p
+	visit(hanoiMove{m, from, p})
+	moveToPeg(state, m-1, p, visit)
+}
+
+// moveFromPeg spreads disks 1..m, all on peg p, to their places in target. The moves are
+// those that gather target on p, played backwards: the largest disk out of place leaves
+// p once the smaller ones have moved to the third peg.
+func moveFromPeg(state hanoiConfig, target hanoiConfig, m int, p int, visit moveVisitor) {
+	for m > 0 && target[m-1] == p {
+		m--
+	}
+	if m == 0 {
+		return
+	}
+	to := target[m-1]
+	other := 3 - to - p
+	moveToPeg(state, m-1, other, visit)
+	state[m-1] = to
+	visit(hanoiMove{m, p, to})
+	moveFromPeg(state, target, m-1, other, visit)
+}
+
+// firstMoveToPeg is the first move of moveToPeg(c, m, p): the smallest disk out of place
+// as the recursion descends.
+func firstMoveToPeg(c hanoiConfig, m int, p int) (hanoiMove, bool) {
+	first, found := hanoiMove{}, false
+	for ; m > 0; m-- {
+		if c[m-1] != p {
+			first, found = hanoiMove{m, c[m-1], p}, true
+			p = 3 - c[m-1] - p
+		}
+	}
+	return first, found
+}
+
+// solveConfig visits an optimal sequence of moves from configuration a to b.
+func solveConfig(a, b hanoiConfig, visit moveVisitor) error {
+	if err := a.validate(b); err != nil {
+		return err
+	}
+	state := append(hanoiConfig{}, a...)
+	m := len(a)
+	for m > 0 && state[m-1] == b[m-1] {
+		m--
+	}
+	if m == 0 {
+		return nil
+	}
+	src, dst := state[m-1], b[m-1]
+	other := 3 - src - dst
+	once, twice := largestMoveCosts(state, b, m)
+	if once <= twice {
+		moveToPeg(state, m-1, other, visit)
+		state[m-1] = dst
+		visit(hanoiMove{m, src, dst})
+		moveFromPeg(state, b, m-1, other, visit)
+		return nil
+	}
+	moveToPeg(state, m-1, dst, visit)
+	state[m-1] = other
+	visit(hanoiMove{m, src, other})
+	moveToPeg(state, m-1, src, visit)
+	state[m-1] = dst
+	visit(hanoiMove{m, other, dst})
+	moveFromPeg(state, b, m-1, src, visit)
+	return nil
+}
+
+// hint returns the first move of an optimal solution and its total number of moves,
+// following the branch solveConfig takes for the largest disk out of place.
+func hint(a, b hanoiConfig) (hanoiMove, uint64, error) {
+	distance, err := configDistance(a, b)
+	if err != nil || distance == 0 {
+		return hanoiMove{}, distance, err
+	}
+	m := len(a)
+	for a[m-1] == b[m-1] {
+		m--
+	}
+	src, dst := a[m-1], b[m-1]
+	other := 3 - src - dst
+	if once, twice := largestMoveCosts(a, b, m); once <= twice {
+		if first, ok := firstMoveToPeg(a, m-1, other); ok {
+			return first, distance, nil
+		}
+		return hanoiMove{m, src, dst}, distance, nil
+	}
+	if first, ok := firstMoveToPeg(a, m-1, dst); ok {
+		return first, distance, nil
+	}
+	return hanoiMove{m, src, other}, distance, nil
+}
@@ -1,6 +1,10 @@
 package main
 
-import "fmt"
+import (
+	"fmt"
+	"log"
+	"os"
+)
 
 type stack struct {
 	container []int
@@ -43,4 +47,67 @@ func main() {
 	fmt.Println("Tower A: ", towerA.container)
 	fmt.Println("Tower B: ", towerB.container)
 	fmt.Println("Tower C: ", towerC.container)
+
+	for _, m := range collectMoves(func(visit moveVisitor) { solveHanoi(3, 0, 2, 1, visit) }) {
+		fmt.Println(m)
+	}
+
+	f := frameStewartSolver{}
+	f.init()
+	for k := 3; k <= 6; k++ {
+		moves, _ := f.moves(10, k)
+		check := towers{}
+		check.init(k, 10, 0)
+		count := 0
+		err := solveTowers(10, k, false, func(m hanoiMove) {
+			count++
+			if err := check.apply(m); err != nil {
+				log.Fatal(err)
+			}
+		})
+		if err != nil {
+			log.Fatal(err)
+		}
+		fmt.Printf("10 disks, %d pegs: %d moves (Frame-Stewart %d), solved: %v\n", k, count, moves, len(check.pegs[k-1].container) == 10)
+	}
+
+	count := 0
+	solveHanoiIterative(20, 0, 2, 1, func(m hanoiMove) { count++ })
+	fmt.Println("20 disks, iterative:", count, "moves")
+
+	if err := animate(os.Stdout, 3, 4, 0); err != nil {
+		log.Fatal(err)
+	}
+
+	illegal := towers{pegs: make([]stack, 3)}
+	illegal.pegs[0].push(1)
+	illegal.pegs[0].push(2)
+	_, err := configFromTowers(illegal)
+	fmt.Println(err)
+
+	scrambled := towers{pegs: make([]stack, 3)}
+	for _, d := range []int{6, 3, 1} {
+		scrambled.pegs[0].push(d)
+	}
+	for _, d := range []int{5, 4} {
+		scrambled.pegs[1].push(d)
+	}
+	scrambled.pegs[2].push(2)
+	from, err := configFromTowers(scrambled)
+	if err != nil {
+		log.Fatal(err)
+	}
+	to := hanoiConfig{2, 2, 0, 1, 1, 2}
+	distance, _ := configDistance(from, to)
+	first, _, _ := hint(from, to)
+	fmt.Println("distance:", distance, "hint:", first)
+	state := from.towers(3)
+	moves := collectMoves(func(visit moveVisitor) { solveConfig(from, to, visit) })
+	for _, m := range moves {
+		if err := state.apply(m); err != nil {
+			log.Fatal(err)
+		}
+	}
+	reached, _ := configFromTowers(state)
+	fmt.Println(len(moves), "moves, reached target:", fmt.Sprint(reached) == fmt.Sprint(to))
 }
@@ -0,0 +1,278 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"log"
+	"math"
+	"strings"
+	"time"
+)
+
+type hanoiMove struct {
+	disk int // 1 is the smallest disk
+	from int
+	to   int
+}
+
+func pegName(p int) string {
+	return string(rune('A' + p))
+}
+
+func (m hanoiMove) String() string {
+	return fmt.Sprintf("disk %d from peg %s to peg %s", m.disk, pegName(m.from), pegName(m.to))
+}
+
+type moveVisitor func(hanoiMove)
+
+// towers keeps disk sizes on every peg, bottom first.
+type towers struct {
+	pegs []stack
+}
+
+func (t *towers) init(pegs int, disks int, start int) {
+	t.pegs = make([]stack, pegs)
+	for d := disks; d >= 1; d-- {
+		t.pegs[start].push(d)
+	}
+}
+
+func (s stack) top() int {
+	if len(s.container) == 0 {
+		return 0
+	}
+	return s.container[len(s.container)-1]
+}
+
+func (t *towers) apply(m hanoiMove) error {
+	if m.from < 0 || m.from >= len(t.pegs) || m.to < 0 || m.to >= len(t.pegs) || m.from == m.to {
+		return fmt.Errorf("illegal move %v: no such peg", m)
+	}
+	from := &t.pegs[m.from]
+	to := &t.pegs[m.to]
+	if from.top() != m.disk {
+		return fmt.Errorf("illegal move %v: disk %d is not on top", m, m.disk)
+	}
+	if to.top() != 0 && to.top() < m.disk {
+		return fmt.Errorf("illegal move %v: disk %d is smaller", m, to.top())
+	}
+	to.push(from.pop())
+	return nil
+}
+
+func collectMoves(solve func(moveVisitor)) []hanoiMove {
+	moves := []hanoiMove{}
+	solve(func(m hanoiMove) { moves = append(moves, m) })
+	return moves
+}
+
+// solveHanoi is the recursive solution of hanoi that reports the moves instead of making them.
+func solveHanoi(n int, begin, end, temp int, visit moveVisitor) {
+	if n == 0 {
+		return
+	}
+	solveHanoi(n-1, begin, temp, end, visit)
+	visit(hanoiMove{n, begin, end})
+	solveHanoi(n-1, temp, end, begin, visit)
+}
+
+// solveHanoiIterative produces the same moves without recursion: odd moves take the
+// smallest disk one peg around the cycle, even moves make the only other legal move.
+func solveHanoiIterative(n int, begin, end, temp int, visit moveVisitor) {
+	if n == 0 {
+		return
+	}
+	t := towers{}
+	t.init(3, n, 0)
+	peg := []int{begin, end, temp}
+	cycle := []int{0, 1, 2} // begin -> end -> temp when n is odd
+	if n%2 == 0 {
+		cycle = []int{0, 2, 1}
+	}
+	smallest := 0
+	total := uint64(1)<<uint(n) - 1
+	if n >= 64 {
+		total = math.MaxUint64
+	}
+	for i := uint64(1); i <= total; i++ {
+		var m hanoiMove
+		if i%2 == 1 {
+			next := cycle[(indexOf(cycle, smallest)+1)%3]
+			m = hanoiMove{1, smallest, next}
+			smallest = next
+		} else {
+			a, b := (smallest+1)%3, (smallest+2)%3
+			if t.pegs[a].top() == 0 || (t.pegs[b].top() != 0 && t.pegs[b].top() < t.pegs[a].top()) {
+				a, b = b, a
+			}
+			m = hanoiMove{t.pegs[a].top(), a, b}
+		}
+		t.apply(m)
+		visit(hanoiMove{m.disk, peg[m.from], peg[m.to]})
+	}
+}
+
+func indexOf(list []int, value int) int {
+	for i, v := range list {
+		if v == value {
+			return i
+		}
+	}
+	return -1
+}
+
+type frameStewartKey struct {
+	disks int
+	pegs  int
+}
+
+type frameStewartSolver struct {
+	memo map[frameStewartKey][2]uint64 // moves, disks moved aside first
+}
+
+func (f *frameStewartSolver) init() {
+	f.memo = make(map[frameStewartKey][2]uint64)
+}
+
+func saturatingAdd(a, b uint64) uint64 {
+	if a > math.MaxUint64-b {
+		return math.MaxUint64
+	}
+	return a + b
+}
+
+// moves returns the Frame-Stewart move count for n disks and k pegs, and the number t of
+// disks that are parked on an intermediate peg: FS(n,k) = min over t of 2 FS(t,k) + FS(n-t,k-1).
+func (f *frameStewartSolver) moves(n, k int) (uint64, int) {
+	if n == 0 {
+		return 0, 0
+	}
+	if n == 1 {
+		return 1, 0
+	}
+	if k == 3 {
+		if n >= 64 {
+			return math.MaxUint64, n - 1
+		}
+		return uint64(1)<<uint(n) - 1, n - 1
+	}
+	if v, ok := f.memo[frameStewartKey{n, k}]; ok {
+		return v[0], int(v[1])
+	}
+	best, bestT := uint64(math.MaxUint64), 0
+	for t := 1; t < n; t++ {
+		a, _ := f.moves(t, k)
+		b, _ := f.moves(n-t, k-1)
+		if total := saturatingAdd(saturatingAdd(a, a), b); total < best {
+			best, bestT = total, t
+		}
+	}
+	f.memo[frameStewartKey{n, k}] = [2]uint64{best, uint64(bestT)}
+	return best, bestT
+}
+
+// solve moves disks 1..n from peg from to peg to, using the pegs in free as intermediates.
+func (f *frameStewartSolver) solve(n int, from, to int, free []int, visit moveVisitor) {
+	f.solveRange(1, n, from, to, free, visit)
+}
+
+func (f *frameStewartSolver) solveRange(smallest, largest int, from, to int, free []int, visit moveVisitor) {
+	n := largest - smallest + 1
+	if n <= 0 {
+		return
+	}
+	if n == 1 {
+		visit(hanoiMove{smallest, from, to})
+		return
+	}
+	if len(free) == 0 {
+		log.Fatal("frame-stewart: no free peg")
+	}
+	if len(free) == 1 {
+		f.solveRange(smallest, largest-1, from, free[0], []int{to}, visit)
+		visit(hanoiMove{largest, from, to})
+		f.solveRange(smallest, largest-1, free[0], to, []int{from}, visit)
+		return
+	}
+	_, t := f.moves(n, len(free)+2)
+	parking := free[0]
+	rest := free[1:]
+	f.solveRange(smallest, smallest+t-1, from, parking, append([]int{to}, rest...), visit)
+	f.solveRange(smallest+t, largest, from, to, rest, visit)
+	f.solveRange(smallest, smallest+t-1, parking, to, append([]int{from}, rest...), visit)
+}
+
+// solveTowers moves n disks from the first to the last of k pegs. The iterative
+// solver only exists for 3 pegs.
+func solveTowers(n int, k int, iterative bool, visit moveVisitor) error {
+	if k < 3 {
+		return fmt.Errorf("need at least 3 pegs, got %d", k)
+	}
+	if k > 3 && iterative {
+		return fmt.Errorf("no iterative solver for %d pegs", k)
+	}
+	if k == 3 && iterative {
+		solveHanoiIterative(n, 0, 2, 1, visit)
+		return nil
+	}
+	free := []int{}
+	for p := 1; p < k-1; p++ {
+		free = append(free, p)
+	}
+	f := frameStewartSolver{}
+	f.init()
+	f.solve(n, 0, k-1, free, visit)
+	return nil
+}
+
+func (t towers) render(disks int) string {
+	var b strings.Builder
+	for level := disks - 1; level >= -1; level-- {
+		for p := range t.pegs {
+			cell := strings.Repeat(" ", disks) + "|" + strings.Repeat(" ", disks)
+			if level >= 0 && level < len(t.pegs[p].container) {
+				d := t.pegs[p].container[level]
+				pad := strings.Repeat(" ", disks-d)
+				cell = pad + strings.Repeat("=", d) + "|" + strings.Repeat("=", d) + pad
+			}
+			if level == -1 {
+				cell = strings.Repeat("-", disks) + pegName(p) + strings.Repeat("-", disks)
+			}
+			b.WriteString(cell)
+			if p < len(t.pegs)-1 {
+				b.WriteString(" ")
+			}
+		}
+		b.WriteString("\n")
+	}
+	return b.String()
+}
+
+// animate draws every step of moving n disks across k pegs. With a delay it redraws in
+// place using ANSI escape codes, otherwise the frames are printed one after the other.
+func animate(w io.Writer, n int, k int, delay time.Duration) error {
+	t := towers{}
+	t.init(k, n, 0)
+	clear := ""
+	if delay > 0 {
+		clear = "\033[H\033[2J"
+	}
+	fmt.Fprintf(w, "%sstart\n%s", clear, t.render(n))
+	step := 0
+	var err error
+	solveErr := solveTowers(n, k, false, func(m hanoiMove) {
+		if err != nil {
+			return
+		}
+		step++
+		if err = t.apply(m); err != nil {
+			return
+		}
+		time.Sleep(delay)
+		fmt.Fprintf(w, "%s%d: %v\n%s", clear, step, m, t.render(n))
+	})
+	if solveErr != nil {
+		return solveErr
+	}
+	return err
+}
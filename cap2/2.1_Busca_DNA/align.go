@@ -0,0 +1,496 @@
+package main
+
+import (
+	"fmt"
+	"log"
+	"math"
+	"strconv"
+	"strings"
+)
+
+type substitutionMatrix struct {
+	name   string
+	scores [256][256]int
+}
+
+func dnaMatrix(match, mismatch int) *substitutionMatrix {
+	m := &substitutionMatrix{name: fmt.Sprintf("DNA %d/%d", match, mismatch)}
+	for a := 0; a < 256; a++ {
+		for b := 0; b < 256; b++ {
+			if a == b && a != 'N' {
+				m.scores[a][b] = match
+			} else {
+				m.scores[a][b] = mismatch
+			}
+		}
+	}
+	return m
+}
+
+const blosum62Table = `
+   A  R  N  D  C  Q  E  G  H  I  L  K  M  F  P  S  T  W  Y  V  B  Z  X  *
+A  4 -1 -2 -2  0 -1 -1  0 -2 -1 -1 -1 -1 -2 -1  1  0 -3 -2  0 -2 -1  0 -4
+R -1  5  0 -2 -3  1  0 -2  0 -3 -2  2 -1 -3 -2 -1 -1 -3 -2 -3 -1  0 -1 -4
+N -2  0  6  1 -3  0  0  0  1 -3 -3  0 -2 -3 -2  1  0 -4 -2 -3  3  0 -1 -4
+D -2 -2  1  6 -3  0  2 -1 -1 -3 -4 -1 -3 -3 -1  0 -1 -4 -3 -3  4  1 -1 -4
+C  0 -3 -3 -3  9 -3 -4 -3 -3 -1 -1 -3 -1 -2 -3 -1 -1 -2 -2 -1 -3 -3 -2 -4
+Q -1  1  0  0 -3  5  2 -2  0 -3 -2  1  0 -3 -1  0 -1 -2 -1 -2  0  3 -1 -4
+E -1  0  0  2 -4  2  5 -2  0 -3 -3  1 -2 -3 -1  0 -1 -3 -2 -2  1  4 -1 -4
+G  0 -2  0 -1 -3 -2 -2  6 -2 -4 -4 -2 -3 -3 -2  0 -2 -2 -3 -3 -1 -2 -1 -4
+H -2  0  1 -1 -3  0  0 -2  8 -3 -3 -1 -2 -1 -2 -1 -2 -2  2 -3  0  0 -1 -4
+I -1 -3 -3 -3 -1 -3 -3 -4 -3  4  2 -3  1  0 -3 -2 -1 -3 -1  3 -3 -3 -1 -4
+L -1 -2 -3 -4 -1 -2 -3 -4 -3  2  4 -2  2  0 -3 -2 -1 -2 -1  1 -4 -3 -1 -4
+K -1  2  0 -1 -3  1  1 -2 -1 -3 -2  5 -1 -3 -1  0 -1 -3 -2 -2  0  1 -1 -4
+M -1 -1 -2 -3 -1  0 -2 -3 -2  1  2 -1  5  0 -2 -1 -1 -1 -1  1 -3 -1 -1 -4
+F -2 -3 -3 -3 -2 -3 -3 -3 -1  0  0 -3  0  6 -4 -2 -2  1  3 -1 -3 -3 -1 -4
+P -1 -2 -2 -1 -3 -1 -1 -2 -2 -3 -3 -1 -2 -4  7 -1 -1 -4 -3 -2 -2 -1 -2 -4
+S  1 -1  1  0 -1  0  0  0 -1 -2 -2  0 -1 -2 -1  4  1 -3 -2 -2  0  0  0 -4
+T  0 -1  0 -1 -1 -1 -1 -2 -2 -1 -1 -1 -1 -2 -1  1  5 -2 -2  0 -1 -1  0 -4
+W -3 -3 -4 -4 -2 -2 -3 -2 -2 -3 -2 -3 -1  1 -4 -3 -2 11  2 -3 -4 -3 -2 -4
+Y -2 -2 -2 -3 -2 -1 -2 -3  2 -1 -1 -2 -1  3 -3 -2 -2  2  7 -1 -3 -2 -1 -4
+V  0 -3 -3 -3 -1 -2 -2 -3 -3  3  1 -2  1 -1 -2 -2  0 -3 -1  4 -3 -2 -1 -4
+B -2 -1  3  4 -3  0  1 -1  0 -3 -4  0 -3 -3 -2  0 -1 -4 -3 -3  4  1 -1 -4
+Z -1  0  0  1 -3  3  4 -2  0 -3 -3  1 -1 -3 -1  0 -1 -3 -2 -2  1  4 -1 -4
+X  0 -1 -1 -1 -2 -1 -1 -1 -1 -1 -1 -1 -1 -1 -2  0  0 -2 -1 -1 -1 -1 -1 -4
+* -4 -4 -4 -4 -4 -4 -4 -4 -4 -4 -4 -4 -4 -4 -4 -4 -4 -4 -4 -4 -4 -4 -4  1
+`
+
+// parseMatrix reads a substitution matrix in the NCBI text layout. Letters missing from
+// the table score like X.
+func parseMatrix(name, table string) *substitutionMatrix {
+	m := &substitutionMatrix{name: name}
+	lines := strings.Split(strings.TrimSpace(table), "\n")
+	header := strings.Fields(lines[0])
+	for _, line := range lines[1:] {
+		fields := strings.Fields(line)
+		if len(fields) != len(header)+1 {
+			log.Fatalf("matrix %s: bad row %q", name, line)
+		}
+		for i, f := range fields[1:] {
+			v, err := strconv.Atoi(f)
+			if err != nil {
+				log.Fatalf("matrix %s: %v", name, err)
+			}
+			m.scores[fields[0][0]][header[i][0]] = v
+		}
+	}
+	known := map[byte]bool{}
+	for _, h := range header {
+		known[h[0]] = true
+	}
+	x := byte('X')
+	for a := 0; a < 256; a++ {
+		for b := 0; b < 256; b++ {
+			switch {
+			case known[byte(a)] && known[byte(b)]:
+			case known[byte(a)]:
+				m.scores[a][b] = m.scores[a][x]
+			case known[byte(b)]:
+				m.scores[a][b] = m.scores[x][b]
+			default:
+				m.scores[a][b] = m.scores[x][x]
+			}
+		}
+	}
+	return m
+}
+
+var blosum62 = parseMatrix("BLOSUM62", blosum62Table)
+
+// A gap of length L scores -(gapOpen + L*gapExtend), the BLAST convention.
+type scoring struct {
+	matrix    *substitutionMatrix
+	gapOpen   int
+	gapExtend int
+}
+
+func (s scoring) score(a, b byte) int {
+	return s.matrix.scores[a][b]
+}
+
+func (s scoring) gap(length int) int {
+	if length == 0 {
+		return 0
+	}
+	return s.gapOpen + length*s.gapExtend
+}
+
+type alignment struct {
+	score    int
+	alignedA string
+	alignedB string
+	startA   int // where the alignment starts in a and b; 0 for global alignments
+	startB   int
+}
+
+// cigar describes b against a: M for an aligned pair, I for a base only in b and D for a base only in a.
+func (al alignment) cigar() string {
+	var b strings.Builder
+	count := 0
+	var last byte
+	for i := 0; i < len(al.alignedA); i++ {
+		op := byte('M')
+		if al.alignedA[i] == '-' {
+			op = 'I'
+		} else if al.alignedB[i] == '-' {
+			op = 'D'
+		}
+		if op != last && count > 0 {
+			fmt.Fprintf(&b, "%d%c", count, last)
+			count = 0
+		}
+		last = op
+		count++
+	}
+	if count > 0 {
+		fmt.Fprintf(&b, "%d%c", count, last)
+	}
+	return b.String()
+}
+
+func (al alignment) String() string {
+	var middle strings.Builder
+	for i := 0; i < len(al.alignedA); i++ {
+		switch {
+		case al.alignedA[i] == al.alignedB[i]:
+			middle.WriteByte('|')
+		case al.alignedA[i] == '-' || al.alignedB[i] == '-':
+			middle.WriteByte(' ')
+		default:
+			middle.WriteByte('.')
+		}
+	}
+	return fmt.Sprintf("score %d, cigar %s, start %d/%d\n%s\n%s\n%s", al.score, al.cigar(), al.startA, al.startB,
+		al.alignedA, middle.String(), al.alignedB)
+}
+
+// rescore computes the score of an alignment, so the linear-space mode can report it.
+func (s scoring) rescore(alignedA, alignedB string) int {
+	score := 0
+	gapA, gapB := 0, 0
+	for i := 0; i < len(alignedA); i++ {
+		switch {
+		case alignedA[i] == '-':
+			gapA++
+			score -= s.gap(gapB)
+			gapB = 0
+		case alignedB[i] == '-':
+			gapB++
+			score -= s.gap(gapA)
+			gapA = 0
+		default:
+			score -= s.gap(gapA) + s.gap(gapB)
+			gapA, gapB = 0, 0
+			score += s.score(alignedA[i], alignedB[i])
+		}
+	}
+	return score - s.gap(gapA) - s.gap(gapB)
+}
+
+const negInf = math.MinInt32 / 2
+
+const (
+	stateM byte = iota // a[i] aligned with b[j]
+	stateX             // a[i] against a gap
+	stateY             // b[j] against a gap
+	stateStart
+)
+
+func max3(a, b, c int) (int, byte) {
+	if a >= b && a >= c {
+		return a, stateM
+	}
+	if b >= c {
+		return b, stateX
+	}
+	return c, stateY
+}
+
+// gotoh aligns a and b with affine gaps in O(len(a)*len(b)) space, globally
+// (Needleman-Wunsch) or locally (Smith-Waterman).
+func gotoh(a, b string, s scoring, local bool) alignment {
+	n, m := len(a), len(b)
+	trace := [3][][]byte{}
+	for k := range trace {
+		trace[k] = make([][]byte, n+1)
+		for i := range trace[k] {
+			trace[k][i] = make([]byte, m+1)
+		}
+	}
+	prevM, prevX, prevY := make([]int, m+1), make([]int, m+1), make([]int, m+1)
+	curM, curX, curY := make([]int, m+1), make([]int, m+1), make([]int, m+1)
+
+	for j := 0; j <= m; j++ {
+		prevM[j], prevX[j], prevY[j] = negInf, negInf, negInf
+		if j > 0 && !local {
+			prevY[j] = -s.gap(j)
+			trace[stateY][0][j] = stateY
+		}
+	}
+	prevM[0] = 0
+	trace[stateM][0][0] = stateStart
+
+	bestScore, bestI, bestJ := 0, 0, 0
+	for i := 1; i <= n; i++ {
+		curM[0], curX[0], curY[0] = negInf, negInf, negInf
+		if !local {
+			curX[0] = -s.gap(i)
+			trace[stateX][i][0] = stateX
+		}
+		for j := 1; j <= m; j++ {
+			v, from := max3(prevM[j-1], prevX[j-1], prevY[j-1])
+			if local && v <= 0 {
+				v, from = 0, stateStart
+			}
+			curM[j] = v + s.score(a[i-1], b[j-1])
+			trace[stateM][i][j] = from
+
+			curX[j], trace[stateX][i][j] = max3(prevM[j]-s.gapOpen-s.gapExtend, prevX[j]-s.gapExtend, prevY[j]-s.gapOpen-s.gapExtend)
+			curY[j], trace[stateY][i][j] = max3(curM[j-1]-s.gapOpen-s.gapExtend, curX[j-1]-s.gapOpen-s.gapExtend, curY[j-1]-s.gapExtend)
+
+			if local && curM[j] > bestScore {
+				bestScore, bestI, bestJ = curM[j], i, j
+			}
+		}
+		prevM, curM = curM, prevM
+		prevX, curX = curX, prevX
+		prevY, curY = curY, prevY
+	}
+
+	i, j := n, m
+	var state byte
+	if local {
+		i, j, state = bestI, bestJ, stateM
+		if bestScore == 0 {
+			return alignment{0, "", "", 0, 0}
+		}
+	} else {
+		bestScore, state = max3(prevM[m], prevX[m], prevY[m])
+	}
+
+	var ra, rb []byte
+	for state != stateStart && (i > 0 || j > 0) {
+		from := trace[state][i][j]
+		switch state {
+		case stateM:
+			ra, rb = append(ra, a[i-1]), append(rb, b[j-1])
+			i, j = i-1, j-1
+		case stateX:
+			ra, rb = append(ra, a[i-1]), append(rb, '-')
+			i--
+		case stateY:
+			ra, rb = append(ra, '-'), append(rb, b[j-1])
+			j--
+		}
+		state = from
+	}
+	return alignment{bestScore, reverseString(ra), reverseString(rb), i, j}
+}
+
+func reverseString(b []byte) string {
+	for i, j := 0, len(b)-1; i < j; i, j = i+1, j-1 {
+		b[i], b[j] = b[j], b[i]
+	}
+	return string(b)
+}
+
+func needlemanWunsch(a, b string, s scoring) alignment {
+	return gotoh(a, b, s, false)
+}
+
+func smithWaterman(a, b string, s scoring) alignment {
+	return gotoh(a, b, s, true)
+}
+
+// hirschberg builds global alignments in linear space with the Myers-Miller version of
+// Hirschberg's divide and conquer for affine gaps.
+type hirschberg struct {
+	s      scoring
+	a, b   string
+	ra, rb []byte
+	cc, dd []int
+	rr, ss []int
+}
+
+func (h *hirschberg) emitPair(i, j int) {
+	h.ra = append(h.ra, h.a[i])
+	h.rb = append(h.rb, h.b[j])
+}
+
+func (h *hirschberg) emitDelete(i, n int) {
+	for k := 0; k < n; k++ {
+		h.ra = append(h.ra, h.a[i+k])
+		h.rb = append(h.rb, '-')
+	}
+}
+
+func (h *hirschberg) emitInsert(j, n int) {
+	for k := 0; k < n; k++ {
+		h.ra = append(h.ra, '-')
+		h.rb = append(h.rb, h.b[j+k])
+	}
+}
+
+// diff aligns a[i0:i0+m] with b[j0:j0+n]. tb and te are the gap open penalties charged
+// for a deletion touching the start or the end: 0 when it continues a deletion outside.
+func (h *hirschberg) diff(i0, m, j0, n int, tb, te int) {
+	g, e := h.s.gapOpen, h.s.gapExtend
+	if n == 0 {
+		h.emitDelete(i0, m)
+		return
+	}
+	if m == 0 {
+		h.emitInsert(j0, n)
+		return
+	}
+	if m == 1 {
+		// Either delete a[i0] next to a boundary and insert all of b, or align it with some b[j].
+		best := -(min(tb, te) + e) - h.s.gap(n)
+		bestJ := -1
+		for j := 0; j < n; j++ {
+			c := -h.s.gap(j) + h.s.score(h.a[i0], h.b[j0+j]) - h.s.gap(n-1-j)
+			if c > best {
+				best, bestJ = c, j
+			}
+		}
+		if bestJ < 0 {
+			if tb <= te {
+				h.emitDelete(i0, 1)
+				h.emitInsert(j0, n)
+			} else {
+				h.emitInsert(j0, n)
+				h.emitDelete(i0, 1)
+			}
+			return
+		}
+		h.emitInsert(j0, bestJ)
+		h.emitPair(i0, j0+bestJ)
+		h.emitInsert(j0+bestJ+1, n-1-bestJ)
+		return
+	}
+
+	mid := m / 2
+	cc, dd, rr, ss := h.cc, h.dd, h.rr, h.ss
+
+	// Forward scores: cc[j] is the best alignment of a[:mid] with b[:j], dd[j] the best ending in a deletion.
+	cc[0] = 0
+	t := -g
+	for j := 1; j <= n; j++ {
+		t -= e
+		cc[j] = t
+		dd[j] = t - g
+	}
+	t = -tb
+	for i := 1; i <= mid; i++ {
+		diag := cc[0]
+		t -= e
+		c := t
+		cc[0] = c
+		ins := t - g
+		for j := 1; j <= n; j++ {
+			ins = max(ins, c-g) - e
+			dd[j] = max(dd[j], cc[j]-g) - e
+			c = max(dd[j], ins, diag+h.s.score(h.a[i0+i-1], h.b[j0+j-1]))
+			diag = cc[j]
+			cc[j] = c
+		}
+	}
+	dd[0] = cc[0]
+
+	// Reverse scores for a[mid:] against b[j:].
+	rr[n] = 0
+	t = -g
+	for j := n - 1; j >= 0; j-- {
+		t -= e
+		rr[j] = t
+		ss[j] = t - g
+	}
+	t = -te
+	for i := m - 1; i >= mid; i-- {
+		diag := rr[n]
+		t -= e
+		c := t
+		rr[n] = c
+		ins := t - g
+		for j := n - 1; j >= 0; j-- {
+			ins = max(ins, c-g) - e
+			ss[j] = max(ss[j], rr[j]-g) - e
+			c = max(ss[j], ins, diag+h.s.score(h.a[i0+i], h.b[j0+j]))
+			diag = rr[j]
+			rr[j] = c
+		}
+	}
+	ss[n] = rr[n]
+
+	// Cross the middle row either on an aligned pair boundary or inside a deletion.
+	bestJ, best, throughGap := 0, cc[0]+rr[0], false
+	for j := 0; j <= n; j++ {
+		if c := cc[j] + rr[j]; c > best {
+			bestJ, best, throughGap = j, c, false
+		}
+		if c := dd[j] + ss[j] + g; c > best {
+			bestJ, best, throughGap = j, c, true
+		}
+	}
+	if !throughGap {
+		h.diff(i0, mid, j0, bestJ, tb, g)
+		h.diff(i0+mid, m-mid, j0+bestJ, n-bestJ, g, te)
+		return
+	}
+	h.diff(i0, mid-1, j0, bestJ, tb, 0)
+	h.emitDelete(i0+mid-1, 2)
+	h.diff(i0+mid+1, m-mid-1, j0+bestJ, n-bestJ, 0, te)
+}
+
+func hirschbergAlign(a, b string, s scoring) alignment {
+	h := hirschberg{s: s, a: a, b: b}
+	h.cc, h.dd = make([]int, len(b)+1), make([]int, len(b)+1)
+	h.rr, h.ss = make([]int, len(b)+1), make([]int, len(b)+1)
+	h.diff(0, len(a), 0, len(b), s.gapOpen, s.gapOpen)
+	alignedA, alignedB := string(h.ra), string(h.rb)
+	return alignment{s.rescore(alignedA, alignedB), alignedA, alignedB, 0, 0}
+}
+
+// localEnd scans a against b keeping one row per state and returns the best
+// Smith-Waterman score with the end of the alignment that reaches it.
+func localEnd(a, b string, s scoring, anchored bool) (int, int, int) {
+	m := len(b)
+	M, X, Y := make([]int, m+1), make([]int, m+1), make([]int, m+1)
+	for j := range M {
+		M[j], X[j], Y[j] = negInf, negInf, negInf
+	}
+	M[0] = 0
+	best, bestI, bestJ := 0, 0, 0
+	for i := 1; i <= len(a); i++ {
+		diagM, diagX, diagY := M[0], X[0], Y[0]
+		M[0], X[0], Y[0] = negInf, negInf, negInf
+		for j := 1; j <= m; j++ {
+			v, _ := max3(diagM, diagX, diagY)
+			if !anchored || (i == 1 && j == 1) {
+				v = max(v, 0)
+			}
+			diagM, diagX, diagY = M[j], X[j], Y[j]
+			M[j] = v + s.score(a[i-1], b[j-1])
+			X[j] = max(diagM-s.gapOpen-s.gapExtend, diagX-s.gapExtend, diagY-s.gapOpen-s.gapExtend)
+			Y[j] = max(M[j-1]-s.gapOpen-s.gapExtend, X[j-1]-s.gapOpen-s.gapExtend, Y[j-1]-s.gapExtend)
+			if M[j] > best {
+				best, bestI, bestJ = M[j], i, j
+			}
+		}
+	}
+	return best, bestI, bestJ
+}
+
+// smithWatermanLinear finds the end of the best local alignment, then its start by
+// scanning the reversed prefixes from that end, and aligns the region with hirschbergAlign.
+func smithWatermanLinear(a, b string, s scoring) alignment {
+	best, endA, endB := localEnd(a, b, s, false)
+	if best == 0 {
+		return alignment{0, "", "", 0, 0}
+	}
+	ra, rb := []byte(a[:endA]), []byte(b[:endB])
+	_, lenA, lenB := localEnd(reverseString(ra), reverseString(rb), s, true)
+	al := hirschbergAlign(a[endA-lenA:endA], b[endB-lenB:endB], s)
+	al.startA, al.startB = endA-lenA, endB-lenB
+	return al
+}
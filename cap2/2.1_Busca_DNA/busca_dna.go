@@ -2,7 +2,12 @@ package main
 
 import (
 	"fmt"
+	"log"
+	"math/rand"
+	"os"
 	"sort"
+	"strconv"
+	"strings"
 )
 
 type codon [3]byte
@@ -17,7 +22,7 @@ func (g gene) Len() int { return len(g) }
 func (g gene) Swap(i, j int) { g[i], g[j] = g[j], g[i] }
 
 func (g gene) Less(i, j int) bool {
-	return strCodon(g[i]) < strCodon(g[j])
+	return compareCodons(g[i], g[j]) < 0
 }
 
 func (g gene) String() string {
@@ -28,14 +33,15 @@ func (g gene) String() string {
 	return fmt.Sprintf("%s", str)
 }
 
-func (g *gene) loadGene(s string) {
+// loadGene appends the codons of frame 0 and returns how many trailing bases did not fill a codon.
+func (g *gene) loadGene(s string) int {
 	for i := 0; i < len(s); i += 3 {
 		if (i + 2) >= len(s) {
-			return
+			return len(s) - i
 		}
 		*g = append(*g, codon{s[i], s[i+1], s[i+2]})
 	}
-	return
+	return 0
 }
 
 func (g gene) linearContains(c codon) bool {
@@ -52,9 +58,9 @@ func (g gene) binaryContains(c codon) bool {
 	high := len(g) - 1
 	for low <= high {
 		mid := (low + high) / 2
-		if strCodon(g[mid]) < strCodon(c) {
+		if cmp := compareCodons(g[mid], c); cmp < 0 {
 			low = mid + 1
-		} else if strCodon(g[mid]) > strCodon(c) {
+		} else if cmp > 0 {
 			high = mid - 1
 		} else {
 			return true
@@ -64,6 +70,29 @@ func (g gene) binaryContains(c codon) bool {
 }
 
 func main() {
+	if len(os.Args) > 1 && os.Args[1] == "bench" {
+		n := 1000000
+		if len(os.Args) > 2 {
+			if v, err := strconv.Atoi(os.Args[2]); err == nil && v > 100 {
+				n = v
+			}
+		}
+		benchmarkSearch(n, 42)
+		return
+	}
+	if len(os.Args) == 4 && os.Args[1] == "orfs" {
+		id, err := strconv.Atoi(os.Args[2])
+		gc, ok := lookupGeneticCode(id)
+		if err != nil || !ok {
+			fmt.Fprintf(os.Stderr, "unknown translation table %q\n", os.Args[2])
+			os.Exit(1)
+		}
+		for _, orf := range findORFs(strings.ToUpper(os.Args[3]), gc, 3) {
+			fmt.Println(orf)
+		}
+		return
+	}
+
 	g := gene{}
 	g.loadGene("ACTGACTGACTGACTGCGATCGATAAATTGGCGAGTCGAGCTAGCTAGCGGATGCGGATGAGCGCGCGCG")
 	fmt.Println(g)
@@ -76,4 +105,70 @@ func main() {
 	fmt.Println(g.binaryContains(gac))
 	fmt.Println(g.binaryContains(gtc))
 
+	sequence := "ACTGACTGACTGACTGCGATCGATAAATTGGCGAGTCGAGCTAGCTAGCGGATGCGGATGAGCGCGCGCG"
+	ci := codonIndex{}
+	ci.init(sequence)
+	for _, hit := range ci.find(gac) {
+		fmt.Printf("GAC in frame %v, codon %d, position %d\n", hit.frame, hit.index, hit.position)
+	}
+	for _, f := range allFrames() {
+		fmt.Printf("%v %s\n", f, standardCode.translate(frameGene(sequence, f)))
+	}
+
+	orfSequence := "CCATGGCTTGGAAAGCTTAAGGCATGCGTTTACGCATAACAT" + reverseComplement("ATGAAACCCGGGTTTTAG") + "GG"
+	for _, gc := range geneticCodes {
+		fmt.Println(gc.name)
+		for _, orf := range findORFs(orfSequence, gc, 3) {
+			fmt.Println(orf)
+		}
+	}
+
+	fmt.Println("KMP:", kmpSearch(sequence, "GCTAG"), "Horspool:", horspoolSearch(sequence, "GCTAG"))
+	sa := suffixArray{}
+	sa.init(sequence)
+	fmt.Println("suffix array:", sa.lookup("GCTAG"), "longest repeat:", sa.longestRepeat())
+	fmt.Println("1 mismatch:", mismatchSearch(sequence, "GGATGC", 1))
+	fmt.Println("1 edit:", editSearch(sequence, "GGATGAGC", 1))
+
+	dna := scoring{dnaMatrix(2, -3), 5, 2}
+	fmt.Println(needlemanWunsch("GATTACAGATTACA", "GATCACAGGATTTACA", dna))
+	fmt.Println(smithWaterman("TTTTGATTACAGGGG", "CCGATCACACC", dna))
+	protein := scoring{blosum62, 11, 1}
+	fmt.Println(needlemanWunsch("HEAGAWGHEE", "PAWHEAE", protein))
+	fmt.Println(smithWaterman("MKTAYIAKQRQISFVKSHFSRQ", "GSKQRQISFVKAHF", protein))
+
+	rnd := rand.New(rand.NewSource(7))
+	long := randomDNA(20000, rnd)
+	mutated := []byte(long[1000:19000])
+	for i := 0; i < len(mutated); i += 97 {
+		mutated[i] = "ACGT"[rnd.Intn(4)]
+	}
+	linear := hirschbergAlign(long, string(mutated), dna)
+	fmt.Printf("hirschberg %d x %d: score %d, cigar %.40s...\n", len(long), len(mutated), linear.score, linear.cigar())
+	local := smithWatermanLinear(long, string(mutated[5000:6000]), dna)
+	fmt.Printf("linear-space local: score %d, starts at %d/%d, cigar %s\n", local.score, local.startA, local.startB, local.cigar())
+
+	fmt.Println("codons:", len(g.codonCounts()), "top 4-mers:", topKmers(countKmers(sequence, 4, false), 3))
+	fmt.Println("top canonical 4-mers:", topKmers(countKmers(sequence, 4, true), 3))
+
+	genome := randomDNA(5000, rnd)
+	reads := []string{}
+	for i := 0; i < 600; i++ {
+		start := rnd.Intn(len(genome) - 100)
+		read := []byte(genome[start : start+100])
+		if rnd.Intn(10) == 0 {
+			read[rnd.Intn(len(read))] = "ACGT"[rnd.Intn(4)]
+		}
+		if rnd.Intn(2) == 0 {
+			read = []byte(reverseComplement(string(read)))
+		}
+		reads = append(reads, string(read))
+	}
+	graph := deBruijnGraph{}
+	if err := graph.init(reads, 25, 2); err != nil {
+		log.Fatal(err)
+	}
+	contigs := graph.contigs()
+	fmt.Printf("%d reads, %d vertices, %d contigs, N50 %d, longest %d\n", len(reads), len(graph.edges), len(contigs), n50(contigs), len(contigs[0]))
+	fmt.Println("longest contig is in the genome:", strings.Contains(genome, contigs[0]) || strings.Contains(genome, reverseComplement(contigs[0])))
 }
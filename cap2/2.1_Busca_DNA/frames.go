@@ -0,0 +1,230 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+)
+
+type strand int
+
+const (
+	forward strand = iota
+	reverse
+)
+
+// A reading frame is identified by its strand and the offset (0, 1 or 2) of its first codon.
+type readingFrame struct {
+	strand strand
+	offset int
+}
+
+func (f readingFrame) String() string {
+	if f.strand == forward {
+		return fmt.Sprintf("+%d", f.offset+1)
+	}
+	return fmt.Sprintf("-%d", f.offset+1)
+}
+
+func allFrames() []readingFrame {
+	frames := []readingFrame{}
+	for _, s := range []strand{forward, reverse} {
+		for offset := 0; offset < 3; offset++ {
+			frames = append(frames, readingFrame{s, offset})
+		}
+	}
+	return frames
+}
+
+func complement(b byte) byte {
+	switch b {
+	case 'A':
+		return 'T'
+	case 'T':
+		return 'A'
+	case 'C':
+		return 'G'
+	case 'G':
+		return 'C'
+	}
+	return 'N'
+}
+
+func reverseComplement(s string) string {
+	ret := make([]byte, len(s))
+	for i := 0; i < len(s); i++ {
+		ret[len(s)-1-i] = complement(s[i])
+	}
+	return string(ret)
+}
+
+// frameGene loads the codons of one reading frame. Bases after the last full codon are
+// left out, see loadGene.
+func frameGene(s string, f readingFrame) gene {
+	if f.strand == reverse {
+		s = reverseComplement(s)
+	}
+	g := gene{}
+	if f.offset < len(s) {
+		g.loadGene(s[f.offset:])
+	}
+	return g
+}
+
+// position converts a codon index in frame f to the forward-strand coordinate of the
+// codon's first base (for the reverse strand, the highest coordinate the codon covers).
+func (f readingFrame) position(codonIndex int, length int) int {
+	p := f.offset + 3*codonIndex
+	if f.strand == reverse {
+		return length - 1 - p
+	}
+	return p
+}
+
+type codonHit struct {
+	frame    readingFrame
+	index    int // codon index within the frame
+	position int // coordinate on the forward strand
+}
+
+// codonIndex maps every codon to all the places it occurs in the six reading frames.
+type codonIndex struct {
+	length int
+	hits   map[codon][]codonHit
+}
+
+func (ci *codonIndex) init(s string) {
+	ci.length = len(s)
+	ci.hits = make(map[codon][]codonHit)
+	for _, f := range allFrames() {
+		for i, c := range frameGene(s, f) {
+			ci.hits[c] = append(ci.hits[c], codonHit{f, i, f.position(i, len(s))})
+		}
+	}
+}
+
+func (ci codonIndex) find(c codon) []codonHit {
+	return ci.hits[c]
+}
+
+type geneticCode struct {
+	id     int
+	name   string
+	amino  map[codon]byte
+	starts map[codon]bool
+}
+
+// NCBI translation tables are written as 64 amino acids for the codons TTT, TTC, TTA, TTG,
+// TCT, ... in TCAG order, with '*' for stop codons, and the start codons marked with 'M'.
+func newGeneticCode(id int, name string, aminoAcids string, starts string) geneticCode {
+	bases := "TCAG"
+	gc := geneticCode{id, name, make(map[codon]byte), make(map[codon]bool)}
+	i := 0
+	for _, a := range []byte(bases) {
+		for _, b := range []byte(bases) {
+			for _, c := range []byte(bases) {
+				cod := codon{a, b, c}
+				gc.amino[cod] = aminoAcids[i]
+				if starts[i] == 'M' {
+					gc.starts[cod] = true
+				}
+				i++
+			}
+		}
+	}
+	return gc
+}
+
+var standardCode = newGeneticCode(1, "Standard",
+	"FFLLSSSSYY**CC*WLLLLPPPPHHQQRRRRIIIMTTTTNNKKSSRRVVVVAAAADDEEGGGG",
+	"---M------**--*----M---------------M----------------------------")
+
+var vertebrateMitochondrialCode = newGeneticCode(2, "Vertebrate Mitochondrial",
+	"FFLLSSSSYY**CCWWLLLLPPPPHHQQRRRRIIMMTTTTNNKKSS**VVVVAAAADDEEGGGG",
+	"----------**--------------------MMMM----------**---M------------")
+
+var yeastMitochondrialCode = newGeneticCode(3, "Yeast Mitochondrial",
+	"FFLLSSSSYY**CCWWTTTTPPPPHHQQRRRRIIMMTTTTNNKKSSRRVVVVAAAADDEEGGGG",
+	"----------**----------------------MM---------------M------------")
+
+var bacterialCode = newGeneticCode(11, "Bacterial, Archaeal and Plant Plastid",
+	"FFLLSSSSYY**CC*WLLLLPPPPHHQQRRRRIIIMTTTTNNKKSSRRVVVVAAAADDEEGGGG",
+	"---M------**--*----M------------MMMM---------------M------------")
+
+var geneticCodes = []geneticCode{standardCode, vertebrateMitochondrialCode, yeastMitochondrialCode, bacterialCode}
+
+// lookupGeneticCode finds a translation table by its NCBI number.
+func lookupGeneticCode(id int) (geneticCode, bool) {
+	for _, gc := range geneticCodes {
+		if gc.id == id {
+			return gc, true
+		}
+	}
+	return geneticCode{}, false
+}
+
+func (gc geneticCode) translateCodon(c codon) byte {
+	if a, ok := gc.amino[c]; ok {
+		return a
+	}
+	return 'X'
+}
+
+func (gc geneticCode) isStop(c codon) bool {
+	return gc.translateCodon(c) == '*'
+}
+
+func (gc geneticCode) translate(g gene) string {
+	var b strings.Builder
+	for _, c := range g {
+		b.WriteByte(gc.translateCodon(c))
+	}
+	return b.String()
+}
+
+type openReadingFrame struct {
+	frame   readingFrame
+	start   int // first forward-strand coordinate covered, start codon included
+	end     int // last forward-strand coordinate covered, stop codon included
+	protein string
+}
+
+func (o openReadingFrame) String() string {
+	return fmt.Sprintf("%v %d..%d %s", o.frame, o.start, o.end, o.protein)
+}
+
+// findORFs looks in the six frames for start ... stop stretches that code for at least
+// minLength amino acids, using the start codons of gc. An alternative start codon is
+// still read as methionine. Nested starts inside an open frame are not reported separately.
+func findORFs(s string, gc geneticCode, minLength int) []openReadingFrame {
+	orfs := []openReadingFrame{}
+	for _, f := range allFrames() {
+		g := frameGene(s, f)
+		for i := 0; i < len(g); i++ {
+			if !gc.starts[g[i]] {
+				continue
+			}
+			j := i
+			for j < len(g) && !gc.isStop(g[j]) {
+				j++
+			}
+			if j == len(g) {
+				break // no stop codon before the end of the sequence
+			}
+			if j-i >= minLength {
+				first := f.position(i, len(s))
+				last := f.position(j, len(s))
+				if f.strand == forward {
+					last += 2
+				} else {
+					last -= 2
+				}
+				if first > last {
+					first, last = last, first
+				}
+				orfs = append(orfs, openReadingFrame{f, first, last, "M" + gc.translate(g[i+1:j])})
+			}
+			i = j
+		}
+	}
+	return orfs
+}
@@ -0,0 +1,219 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+)
+
+var errKmerTooShort = errors.New("k-mer too short for a de Bruijn graph")
+
+func isNucleotides(s string) bool {
+	for i := 0; i < len(s); i++ {
+		switch s[i] {
+		case 'A', 'C', 'G', 'T':
+		default:
+			return false
+		}
+	}
+	return true
+}
+
+// canonicalKmer picks the smaller of a k-mer and its reverse complement, so both
+// strands of the same sequence count together.
+func canonicalKmer(kmer string) string {
+	if rc := reverseComplement(kmer); rc < kmer {
+		return rc
+	}
+	return kmer
+}
+
+// countKmers counts every k-mer of s, skipping those with bases other than A, C, G and T.
+func countKmers(s string, k int, canonical bool) map[string]int {
+	counts := make(map[string]int)
+	if k < 1 {
+		return counts
+	}
+	for i := 0; i+k <= len(s); i++ {
+		kmer := s[i : i+k]
+		if !isNucleotides(kmer) {
+			continue
+		}
+		if canonical {
+			kmer = canonicalKmer(kmer)
+		}
+		counts[kmer]++
+	}
+	return counts
+}
+
+// codonCounts counts the codons of a gene; they are the 3-mers of frame 0.
+func (g gene) codonCounts() map[codon]int {
+	counts := make(map[codon]int)
+	for _, c := range g {
+		counts[c]++
+	}
+	return counts
+}
+
+type kmerCount struct {
+	kmer  string
+	count int
+}
+
+func topKmers(counts map[string]int, n int) []kmerCount {
+	ret := []kmerCount{}
+	for kmer, count := range counts {
+		ret = append(ret, kmerCount{kmer, count})
+	}
+	sort.Slice(ret, func(i, j int) bool {
+		if ret[i].count == ret[j].count {
+			return ret[i].kmer < ret[j].kmer
+		}
+		return ret[i].count > ret[j].count
+	})
+	if len(ret) > n {
+		ret = ret[:n]
+	}
+	return ret
+}
+
+// deBruijnGraph has the (k-1)-mers as vertices and one edge per distinct k-mer.
+// Each k-mer is added together with its reverse complement, so reads from both
+// strands fall into the same graph.
+type deBruijnGraph struct {
+	k     int
+	edges map[string][]string
+	in    map[string]int
+}
+
+// init needs k >= 2, since the vertices are the (k-1)-mers.
+func (d *deBruijnGraph) init(reads []string, k int, minCount int) error {
+	if k < 2 {
+		return fmt.Errorf("%w: k = %d", errKmerTooShort, k)
+	}
+	d.k = k
+	d.edges = make(map[string][]string)
+	d.in = make(map[string]int)
+	counts := make(map[string]int)
+	for _, r := range reads {
+		for kmer, c := range countKmers(r, k, true) {
+			counts[kmer] += c
+		}
+	}
+	kmers := []string{}
+	for kmer, c := range counts {
+		// k-mers seen fewer than minCount times are most likely sequencing errors.
+		if c >= minCount {
+			kmers = append(kmers, kmer)
+		}
+	}
+	sort.Strings(kmers)
+	for _, kmer := range kmers {
+		d.addEdge(kmer)
+		if rc := reverseComplement(kmer); rc != kmer {
+			d.addEdge(rc)
+		}
+	}
+	return nil
+}
+
+func (d *deBruijnGraph) addEdge(kmer string) {
+	from, to := kmer[:d.k-1], kmer[1:]
+	d.edges[from] = append(d.edges[from], to)
+	d.in[to]++
+	if _, ok := d.edges[to]; !ok {
+		d.edges[to] = []string{}
+	}
+}
+
+func (d *deBruijnGraph) isOneInOneOut(v string) bool {
+	return d.in[v] == 1 && len(d.edges[v]) == 1
+}
+
+func (d *deBruijnGraph) vertices() []string {
+	ret := []string{}
+	for v := range d.edges {
+		ret = append(ret, v)
+	}
+	sort.Strings(ret)
+	return ret
+}
+
+// contigs spells the maximal non-branching paths of the graph. Each contig is found
+// once per strand, so only the canonical orientation is kept.
+func (d *deBruijnGraph) contigs() []string {
+	paths := []string{}
+	used := make(map[string]bool)
+	for _, v := range d.vertices() {
+		if d.isOneInOneOut(v) {
+			continue
+		}
+		used[v] = true
+		for _, w := range d.edges[v] {
+			var b strings.Builder
+			b.WriteString(v)
+			for {
+				b.WriteByte(w[len(w)-1])
+				used[w] = true
+				if !d.isOneInOneOut(w) {
+					break
+				}
+				w = d.edges[w][0]
+			}
+			paths = append(paths, b.String())
+		}
+	}
+	// What is left are isolated cycles where every vertex is 1-in-1-out.
+	for _, v := range d.vertices() {
+		if used[v] {
+			continue
+		}
+		var b strings.Builder
+		b.WriteString(v)
+		used[v] = true
+		for w := d.edges[v][0]; w != v; w = d.edges[w][0] {
+			b.WriteByte(w[len(w)-1])
+			used[w] = true
+		}
+		b.WriteByte(v[len(v)-1])
+		paths = append(paths, b.String())
+	}
+
+	seen := make(map[string]bool)
+	ret := []string{}
+	for _, p := range paths {
+		c := canonicalKmer(p)
+		if !seen[c] {
+			seen[c] = true
+			ret = append(ret, c)
+		}
+	}
+	sort.Slice(ret, func(i, j int) bool {
+		if len(ret[i]) == len(ret[j]) {
+			return ret[i] < ret[j]
+		}
+		return len(ret[i]) > len(ret[j])
+	})
+	return ret
+}
+
+// n50 is the length of the contig that, with the longer ones, covers half of the assembly.
+func n50(contigs []string) int {
+	lengths := []int{}
+	total := 0
+	for _, c := range contigs {
+		lengths = append(lengths, len(c))
+		total += len(c)
+	}
+	sort.Sort(sort.Reverse(sort.IntSlice(lengths)))
+	sum := 0
+	for _, l := range lengths {
+		sum += l
+		if 2*sum >= total {
+			return l
+		}
+	}
+	return 0
+}
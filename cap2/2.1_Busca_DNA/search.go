@@ -0,0 +1,334 @@
+package main
+
+import (
+	"fmt"
+	"math/rand"
+	"sort"
+	"strings"
+	"time"
+)
+
+func compareCodons(a, b codon) int {
+	for i := 0; i < 3; i++ {
+		if a[i] != b[i] {
+			if a[i] < b[i] {
+				return -1
+			}
+			return 1
+		}
+	}
+	return 0
+}
+
+// bases spells the gene out again, so the substring searches below can run on it.
+func (g gene) bases() string {
+	var b strings.Builder
+	b.Grow(3 * len(g))
+	for _, c := range g {
+		b.Write(c[:])
+	}
+	return b.String()
+}
+
+// kmpSearch returns every position of pattern in text using Knuth-Morris-Pratt.
+func kmpSearch(text, pattern string) []int {
+	hits := []int{}
+	if len(pattern) == 0 {
+		return hits
+	}
+	failure := make([]int, len(pattern))
+	for i, k := 1, 0; i < len(pattern); i++ {
+		for k > 0 && pattern[i] != pattern[k] {
+			k = failure[k-1]
+		}
+		if pattern[i] == pattern[k] {
+			k++
+		}
+		failure[i] = k
+	}
+	for i, k := 0, 0; i < len(text); i++ {
+		for k > 0 && text[i] != pattern[k] {
+			k = failure[k-1]
+		}
+		if text[i] == pattern[k] {
+			k++
+		}
+		if k == len(pattern) {
+			hits = append(hits, i-k+1)
+			k = failure[k-1]
+		}
+	}
+	return hits
+}
+
+// horspoolSearch returns every position of pattern in text using Boyer-Moore-Horspool.
+func horspoolSearch(text, pattern string) []int {
+	hits := []int{}
+	m := len(pattern)
+	if m == 0 {
+		return hits
+	}
+	shift := [256]int{}
+	for i := range shift {
+		shift[i] = m
+	}
+	for i := 0; i < m-1; i++ {
+		shift[pattern[i]] = m - 1 - i
+	}
+	for pos := 0; pos+m <= len(text); pos += shift[text[pos+m-1]] {
+		if text[pos:pos+m] == pattern {
+			hits = append(hits, pos)
+		}
+	}
+	return hits
+}
+
+type suffixArray struct {
+	text string
+	sa   []int // suffixes in lexicographic order
+	lcp  []int // lcp[i] is the common prefix length of sa[i-1] and sa[i]
+}
+
+// init sorts the suffixes by prefix doubling, with a radix sort on each round,
+// and computes the LCP array with Kasai's algorithm.
+func (s *suffixArray) init(text string) {
+	n := len(text)
+	s.text = text
+	s.sa = make([]int, n)
+	rank := make([]int, n)
+	tmp := make([]int, n)
+	for i := 0; i < n; i++ {
+		s.sa[i] = i
+	}
+	sort.SliceStable(s.sa, func(i, j int) bool { return text[s.sa[i]] < text[s.sa[j]] })
+	for i := 1; i < n; i++ {
+		rank[s.sa[i]] = rank[s.sa[i-1]]
+		if text[s.sa[i]] != text[s.sa[i-1]] {
+			rank[s.sa[i]]++
+		}
+	}
+	buckets := make([]int, n+1)
+	second := make([]int, n)
+	for k := 1; n > 1 && rank[s.sa[n-1]] < n-1; k <<= 1 {
+		// Order by the rank k positions ahead: suffixes too short to have it come first.
+		p := 0
+		for i := n - k; i < n; i++ {
+			second[p] = i
+			p++
+		}
+		for _, i := range s.sa {
+			if i >= k {
+				second[p] = i - k
+				p++
+			}
+		}
+		// Stable counting sort of that order by the rank of the first half.
+		for i := range buckets {
+			buckets[i] = 0
+		}
+		for _, r := range rank {
+			buckets[r+1]++
+		}
+		for i := 1; i < len(buckets); i++ {
+			buckets[i] += buckets[i-1]
+		}
+		for _, i := range second {
+			s.sa[buckets[rank[i]]] = i
+			buckets[rank[i]]++
+		}
+		tmp[s.sa[0]] = 0
+		for i := 1; i < n; i++ {
+			a, b := s.sa[i-1], s.sa[i]
+			tmp[b] = tmp[a]
+			if rank[a] != rank[b] || secondRank(rank, a+k) != secondRank(rank, b+k) {
+				tmp[b]++
+			}
+		}
+		rank, tmp = tmp, rank
+	}
+
+	s.lcp = make([]int, n)
+	h := 0
+	for i := 0; i < n; i++ {
+		if rank[i] == 0 {
+			h = 0
+			continue
+		}
+		j := s.sa[rank[i]-1]
+		for i+h < n && j+h < n && text[i+h] == text[j+h] {
+			h++
+		}
+		s.lcp[rank[i]] = h
+		if h > 0 {
+			h--
+		}
+	}
+}
+
+func secondRank(rank []int, i int) int {
+	if i < len(rank) {
+		return rank[i]
+	}
+	return -1
+}
+
+// lookup returns the sorted positions of pattern, found by binary search on the suffixes.
+func (s suffixArray) lookup(pattern string) []int {
+	n := len(s.sa)
+	prefix := func(i int) string {
+		suffix := s.text[s.sa[i]:]
+		if len(suffix) > len(pattern) {
+			return suffix[:len(pattern)]
+		}
+		return suffix
+	}
+	low := sort.Search(n, func(i int) bool { return prefix(i) >= pattern })
+	high := sort.Search(n, func(i int) bool { return prefix(i) > pattern })
+	hits := append([]int{}, s.sa[low:high]...)
+	sort.Ints(hits)
+	return hits
+}
+
+// longestRepeat returns the longest substring that occurs at least twice.
+func (s suffixArray) longestRepeat() string {
+	best := 0
+	for i, l := range s.lcp {
+		if l > s.lcp[best] {
+			best = i
+		}
+	}
+	if len(s.lcp) == 0 || s.lcp[best] == 0 {
+		return ""
+	}
+	return s.text[s.sa[best] : s.sa[best]+s.lcp[best]]
+}
+
+type approximateHit struct {
+	position int // first base of the match in the text
+	length   int
+	distance int
+}
+
+// mismatchSearch returns the positions where pattern matches text with at most k substitutions.
+func mismatchSearch(text, pattern string, k int) []approximateHit {
+	hits := []approximateHit{}
+	m := len(pattern)
+	for pos := 0; pos+m <= len(text); pos++ {
+		mismatches := 0
+		for i := 0; i < m && mismatches <= k; i++ {
+			if text[pos+i] != pattern[i] {
+				mismatches++
+			}
+		}
+		if mismatches <= k {
+			hits = append(hits, approximateHit{pos, m, mismatches})
+		}
+	}
+	return hits
+}
+
+// editSearch finds the matches of pattern with at most k insertions, deletions or
+// substitutions (Sellers' algorithm). For each end position with a match, the best
+// alignment ending there is reported, keeping the start of that alignment.
+func editSearch(text, pattern string, k int) []approximateHit {
+	m := len(pattern)
+	cost := make([]int, m+1)
+	start := make([]int, m+1)
+	for i := range cost {
+		cost[i] = i
+	}
+	hits := []approximateHit{}
+	for j := 0; j < len(text); j++ {
+		diagCost, diagStart := cost[0], j
+		cost[0], start[0] = 0, j+1
+		for i := 1; i <= m; i++ {
+			upCost, upStart := cost[i], start[i]
+			sub := 0
+			if pattern[i-1] != text[j] {
+				sub = 1
+			}
+			best, bestStart := diagCost+sub, diagStart
+			if c := cost[i-1] + 1; c < best {
+				best, bestStart = c, start[i-1]
+			}
+			if c := upCost + 1; c < best {
+				best, bestStart = c, upStart
+			}
+			if i == 1 && sub == 0 {
+				bestStart = j
+			}
+			cost[i], start[i] = best, bestStart
+			diagCost, diagStart = upCost, upStart
+		}
+		if cost[m] <= k {
+			hit := approximateHit{start[m], j + 1 - start[m], cost[m]}
+			// Neighbouring end positions often extend the same match: keep the closest one.
+			if last := len(hits) - 1; last >= 0 && hits[last].position == hit.position {
+				if hit.distance < hits[last].distance {
+					hits[last] = hit
+				}
+				continue
+			}
+			hits = append(hits, hit)
+		}
+	}
+	return hits
+}
+
+func randomDNA(n int, rnd *rand.Rand) string {
+	b := make([]byte, n)
+	for i := range b {
+		b[i] = "ACGT"[rnd.Intn(4)]
+	}
+	return string(b)
+}
+
+func timeIt(name string, f func() int) {
+	start := time.Now()
+	hits := f()
+	fmt.Printf("%-28s %10d hits %14v\n", name, hits, time.Since(start).Round(time.Microsecond))
+}
+
+func boolToInt(b bool) int {
+	if b {
+		return 1
+	}
+	return 0
+}
+
+// benchmarkSearch compares the codon searches of the chapter with the substring searches
+// on a random sequence of n bases.
+func benchmarkSearch(n int, seed int64) {
+	rnd := rand.New(rand.NewSource(seed))
+	g := gene{}
+	g.loadGene(randomDNA(n, rnd))
+	text := g.bases()
+	n = len(text)
+	c := codon{'G', 'A', 'C'}
+	motif := text[n/2 : n/2+12]
+	fmt.Printf("%d bases, codon %s, motif %s\n", n, strCodon(c), motif)
+
+	timeIt("linearContains (codon)", func() int { return boolToInt(g.linearContains(c)) })
+	sorted := append(gene{}, g...)
+	timeIt("sort + binaryContains", func() int {
+		sort.Sort(sorted)
+		return boolToInt(sorted.binaryContains(c))
+	})
+	timeIt("binaryContains (sorted)", func() int { return boolToInt(sorted.binaryContains(c)) })
+	timeIt("codonIndex build + find", func() int {
+		ci := codonIndex{}
+		ci.init(text)
+		return len(ci.find(c))
+	})
+	timeIt("KMP (codon)", func() int { return len(kmpSearch(text, strCodon(c))) })
+	timeIt("KMP (motif)", func() int { return len(kmpSearch(text, motif)) })
+	timeIt("Horspool (motif)", func() int { return len(horspoolSearch(text, motif)) })
+	sa := suffixArray{}
+	timeIt("suffix array build", func() int {
+		sa.init(text)
+		return 0
+	})
+	timeIt("suffix array (motif)", func() int { return len(sa.lookup(motif)) })
+	timeIt("1 mismatch (motif)", func() int { return len(mismatchSearch(text, motif, 1)) })
+	timeIt("2 edits (motif)", func() int { return len(editSearch(text, motif, 2)) })
+}
@@ -0,0 +1,247 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"math/rand"
+	"sort"
+)
+
+var errUnknownGenerator = errors.New("unknown maze generator")
+
+// Perfect mazes keep their cells on even rows and columns; the odd positions between
+// two cells are walls that a generator carves away to join them.
+type mazeGenerator func(m *maze, rnd *rand.Rand)
+
+var mazeGenerators = map[string]mazeGenerator{
+	"backtracker": recursiveBacktracker,
+	"prim":        randomizedPrim,
+	"kruskal":     randomizedKruskal,
+	"wilson":      wilson,
+}
+
+func generatorNames() []string {
+	names := make([]string, 0, len(mazeGenerators))
+	for name := range mazeGenerators {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// generate builds an r x c perfect maze with the named algorithm, then removes the dead
+// end of each corridor with probability braid to add loops. Start and goal are the
+// bottom left and top right cells.
+func (m *maze) generate(r int, c int, algorithm string, braid float64, seed int64) error {
+	gen, ok := mazeGenerators[algorithm]
+	if !ok {
+		return fmt.Errorf("%w %q (have %v)", errUnknownGenerator, algorithm, generatorNames())
+	}
+	if r < 1 || c < 1 {
+		return fmt.Errorf("invalid maze size %d x %d", r, c)
+	}
+	m.rows = r
+	m.columns = c
+	m.grid = make([]byte, r*c)
+	for i := range m.grid {
+		m.grid[i] = BLOCKED
+	}
+	rnd := rand.New(rand.NewSource(seed))
+	gen(m, rnd)
+	if braid > 0 {
+		m.braid(braid, rnd)
+	}
+	m.start = mazeLocation{0, 0}
+	m.goal = mazeLocation{(c - 1) &^ 1, (r - 1) &^ 1}
+	m.grid[m.index(m.start)] = START
+	m.grid[m.index(m.goal)] = GOAL
+	return nil
+}
+
+func (m maze) cellCount() (int, int) {
+	return (m.columns + 1) / 2, (m.rows + 1) / 2
+}
+
+func (m maze) randomCell(rnd *rand.Rand) mazeLocation {
+	w, h := m.cellCount()
+	return mazeLocation{2 * rnd.Intn(w), 2 * rnd.Intn(h)}
+}
+
+func (m maze) neighbourCells(ml mazeLocation) []mazeLocation {
+	cells := make([]mazeLocation, 0, 4)
+	for _, d := range []mazeLocation{{0, 2}, {0, -2}, {2, 0}, {-2, 0}} {
+		n := mazeLocation{ml.column + d.column, ml.row + d.row}
+		if m.inside(n) {
+			cells = append(cells, n)
+		}
+	}
+	return cells
+}
+
+func (m *maze) carve(a mazeLocation, b mazeLocation) {
+	m.grid[m.index(a)] = EMPTY
+	m.grid[m.index(b)] = EMPTY
+	m.grid[m.index(mazeLocation{(a.column + b.column) / 2, (a.row + b.row) / 2})] = EMPTY
+}
+
+func (m maze) open(a mazeLocation, b mazeLocation) bool {
+	return m.grid[m.index(mazeLocation{(a.column + b.column) / 2, (a.row + b.row) / 2})] != BLOCKED
+}
+
+func recursiveBacktracker(m *maze, rnd *rand.Rand) {
+	visited := map[mazeLocation]bool{}
+	first := m.randomCell(rnd)
+	m.grid[m.index(first)] = EMPTY
+	visited[first] = true
+	path := []mazeLocation{first}
+	for len(path) > 0 {
+		current := path[len(path)-1]
+		unvisited := []mazeLocation{}
+		for _, n := range m.neighbourCells(current) {
+			if !visited[n] {
+				unvisited = append(unvisited, n)
+			}
+		}
+		if len(unvisited) == 0 {
+			path = path[:len(path)-1]
+			continue
+		}
+		next := unvisited[rnd.Intn(len(unvisited))]
+		m.carve(current, next)
+		visited[next] = true
+		path = append(path, next)
+	}
+}
+
+func randomizedPrim(m *maze, rnd *rand.Rand) {
+	type wall struct{ from, to mazeLocation }
+	visited := map[mazeLocation]bool{}
+	walls := []wall{}
+	add := func(ml mazeLocation) {
+		visited[ml] = true
+		for _, n := range m.neighbourCells(ml) {
+			if !visited[n] {
+				walls = append(walls, wall{ml, n})
+			}
+		}
+	}
+	first := m.randomCell(rnd)
+	m.grid[m.index(first)] = EMPTY
+	add(first)
+	for len(walls) > 0 {
+		i := rnd.Intn(len(walls))
+		w := walls[i]
+		walls[i] = walls[len(walls)-1]
+		walls = walls[:len(walls)-1]
+		if !visited[w.to] {
+			m.carve(w.from, w.to)
+			add(w.to)
+		}
+	}
+}
+
+func randomizedKruskal(m *maze, rnd *rand.Rand) {
+	w, h := m.cellCount()
+	parent := make([]int, w*h)
+	for i := range parent {
+		parent[i] = i
+	}
+	var find func(int) int
+	find = func(i int) int {
+		if parent[i] != i {
+			parent[i] = find(parent[i])
+		}
+		return parent[i]
+	}
+	id := func(ml mazeLocation) int { return ml.column/2 + ml.row/2*w }
+
+	type edge struct{ a, b mazeLocation }
+	edges := []edge{}
+	for y := 0; y < h; y++ {
+		for x := 0; x < w; x++ {
+			cell := mazeLocation{2 * x, 2 * y}
+			m.grid[m.index(cell)] = EMPTY
+			if x+1 < w {
+				edges = append(edges, edge{cell, mazeLocation{2*x + 2, 2 * y}})
+			}
+			if y+1 < h {
+				edges = append(edges, edge{cell, mazeLocation{2 * x, 2*y + 2}})
+			}
+		}
+	}
+	rnd.Shuffle(len(edges), func(i, j int) { edges[i], edges[j] = edges[j], edges[i] })
+	for _, e := range edges {
+		ra, rb := find(id(e.a)), find(id(e.b))
+		if ra != rb {
+			parent[ra] = rb
+			m.carve(e.a, e.b)
+		}
+	}
+}
+
+// wilson joins each cell to the maze with a loop-erased random walk, which samples
+// uniformly among all perfect mazes of the grid.
+func wilson(m *maze, rnd *rand.Rand) {
+	w, h := m.cellCount()
+	inMaze := map[mazeLocation]bool{}
+	first := m.randomCell(rnd)
+	m.grid[m.index(first)] = EMPTY
+	inMaze[first] = true
+	for y := 0; y < h; y++ {
+		for x := 0; x < w; x++ {
+			start := mazeLocation{2 * x, 2 * y}
+			if inMaze[start] {
+				continue
+			}
+			// Remembering only the last exit of each cell erases the loops of the walk.
+			exit := map[mazeLocation]mazeLocation{}
+			for current := start; !inMaze[current]; {
+				neighbours := m.neighbourCells(current)
+				exit[current] = neighbours[rnd.Intn(len(neighbours))]
+				current = exit[current]
+			}
+			for current := start; !inMaze[current]; current = exit[current] {
+				m.carve(current, exit[current])
+				inMaze[current] = true
+			}
+		}
+	}
+}
+
+// braid opens one more wall of each dead end with probability p, preferring walls that
+// lead to other dead ends so fewer remain.
+func (m *maze) braid(p float64, rnd *rand.Rand) {
+	w, h := m.cellCount()
+	deadEnd := func(ml mazeLocation) bool {
+		exits := 0
+		for _, n := range m.neighbourCells(ml) {
+			if m.open(ml, n) {
+				exits++
+			}
+		}
+		return exits == 1
+	}
+	for y := 0; y < h; y++ {
+		for x := 0; x < w; x++ {
+			cell := mazeLocation{2 * x, 2 * y}
+			if !deadEnd(cell) || rnd.Float64() >= p {
+				continue
+			}
+			closed, best := []mazeLocation{}, []mazeLocation{}
+			for _, n := range m.neighbourCells(cell) {
+				if !m.open(cell, n) {
+					closed = append(closed, n)
+					if deadEnd(n) {
+						best = append(best, n)
+					}
+				}
+			}
+			if len(best) > 0 {
+				closed = best
+			}
+			if len(closed) > 0 {
+				m.carve(cell, closed[rnd.Intn(len(closed))])
+			}
+		}
+	}
+}
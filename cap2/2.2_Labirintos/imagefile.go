@@ -0,0 +1,116 @@
+package main
+
+import (
+	"fmt"
+	"image"
+	"image/color"
+	"image/draw"
+	_ "image/gif"
+	_ "image/jpeg"
+	"image/png"
+	"os"
+)
+
+// pixelKind tells start and goal pixels apart: clearly green pixels are the start and
+// clearly red ones the goal.
+func pixelKind(c color.Color) byte {
+	r, g, b, _ := c.RGBA()
+	r, g, b = r>>8, g>>8, b>>8
+	switch {
+	case g >= 128 && r < g/2 && b < g/2:
+		return START
+	case r >= 128 && g < r/2 && b < r/2:
+		return GOAL
+	}
+	return EMPTY
+}
+
+// overWhite composites c over a white background, so transparent pixels are open cells.
+func overWhite(c color.Color) color.Color {
+	r, g, b, a := c.RGBA()
+	return color.RGBA64{uint16(r + 0xffff - a), uint16(g + 0xffff - a), uint16(b + 0xffff - a), 0xffff}
+}
+
+// loadImage turns each pixel, seen over white, into a cell: pixels darker than threshold are walls. The
+// start and goal are the first green and red pixels, scanning from the top left.
+func (m *maze) loadImage(img image.Image, threshold uint8) error {
+	bounds := img.Bounds()
+	loaded := maze{rows: bounds.Dy(), columns: bounds.Dx(), grid: make([]byte, bounds.Dx()*bounds.Dy())}
+	if len(loaded.grid) == 0 {
+		return fmt.Errorf("%w: empty image", errMalformedMaze)
+	}
+	starts, goals := 0, 0
+	for y := bounds.Min.Y; y < bounds.Max.Y; y++ {
+		for x := bounds.Min.X; x < bounds.Max.X; x++ {
+			ml := mazeLocation{x - bounds.Min.X, bounds.Max.Y - 1 - y}
+			c := overWhite(img.At(x, y))
+			cell := pixelKind(c)
+			switch {
+			case cell == START:
+				if starts == 0 {
+					loaded.start = ml
+				}
+				starts++
+			case cell == GOAL:
+				if goals == 0 {
+					loaded.goal = ml
+				}
+				goals++
+			case color.GrayModel.Convert(c).(color.Gray).Y < threshold:
+				cell = BLOCKED
+			}
+			loaded.grid[loaded.index(ml)] = cell
+		}
+	}
+	if starts == 0 {
+		return fmt.Errorf("%w: no green start pixel", errMalformedMaze)
+	}
+	if goals == 0 {
+		return fmt.Errorf("%w: no red goal pixel", errMalformedMaze)
+	}
+	// Extra start and goal pixels are ordinary open cells.
+	for i, cell := range loaded.grid {
+		if cell == START || cell == GOAL {
+			loaded.grid[i] = EMPTY
+		}
+	}
+	loaded.grid[loaded.index(loaded.start)] = START
+	loaded.grid[loaded.index(loaded.goal)] = GOAL
+	*m = loaded
+	return nil
+}
+
+func readImage(name string) (image.Image, error) {
+	f, err := os.Open(name)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	img, _, err := image.Decode(f)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", name, err)
+	}
+	return img, nil
+}
+
+// writeSolvedImage saves a copy of the image the maze was loaded from with the path
+// painted over it.
+func (m maze) writeSolvedImage(name string, src image.Image, path []mazeLocation) error {
+	bounds := src.Bounds()
+	img := image.NewRGBA(image.Rect(0, 0, bounds.Dx(), bounds.Dy()))
+	draw.Draw(img, img.Bounds(), src, bounds.Min, draw.Src)
+	for _, ml := range path {
+		if ml != m.start && ml != m.goal {
+			img.Set(ml.column, m.rows-1-ml.row, pathColor)
+		}
+	}
+	f, err := os.Create(name)
+	if err != nil {
+		return err
+	}
+	if err := png.Encode(f, img); err != nil {
+		f.Close()
+		return err
+	}
+	return f.Close()
+}
@@ -1,10 +1,15 @@
 package main
 
 import (
-	"container/heap"
+	"flag"
 	"fmt"
+	"image"
+	"log"
 	"math"
 	"math/rand"
+	"os"
+
+	"github.com/arlima/problemas_classicos_CC/search"
 )
 
 const (
@@ -20,112 +25,57 @@ type mazeLocation struct {
 	row    int
 }
 
-type node struct {
-	state     mazeLocation
-	parent    *node
-	cost      float64
-	heuristic float64
-}
-
-type PriorityQueue []node
-
-func (pq PriorityQueue) Len() int { return len(pq) }
-
-func (pq PriorityQueue) empty() bool { return len(pq) == 0 }
-
-func (pq PriorityQueue) Less(i, j int) bool {
-	return (pq[i].cost + pq[i].heuristic) < (pq[j].cost + pq[j].heuristic)
-}
-
-func (pq PriorityQueue) Swap(i, j int) {
-	pq[i], pq[j] = pq[j], pq[i]
-}
-
-func (pq *PriorityQueue) Push(x interface{}) {
-	item := x.(node)
-	*pq = append(*pq, item)
-}
-
-func (pq *PriorityQueue) Pop() interface{} {
-	old := *pq
-	n := len(old)
-	item := old[n-1]
-	old[n-1] = node{} // avoid memory leak
-	*pq = old[0 : n-1]
-	return item
-}
-
-type stack struct {
-	container []node
-}
-
-func (s stack) empty() bool {
-	return (len(s.container) == 0)
-}
-
-func (s *stack) push(n node) {
-	s.container = append(s.container, n)
-}
-
-func (s *stack) pop() node {
-	p := len(s.container) - 1
-	value := s.container[p]
-	s.container = s.container[:p]
-	return value
-}
-
-type queue struct {
-	container []node
-}
-
-func (q queue) empty() bool {
-	return (len(q.container) == 0)
-}
-
-func (q *queue) push(n node) {
-	q.container = append(q.container, n)
-}
-
-func (q *queue) pop() node {
-	value := q.container[0]
-	q.container = q.container[1:]
-	return value
-}
-
 type maze struct {
 	rows    int
 	columns int
 	grid    []byte
 	start   mazeLocation
 	goal    mazeLocation
+
+	diagonal bool
+	corners  cornerPolicy
+	covered  map[int]byte
+	trace    *mazeTrace
 }
 
-func (m *maze) init(r int, c int, s float64, startPos mazeLocation, goalPos mazeLocation) {
+// init fills an r x c maze with random walls: each cell is blocked with probability s.
+func (m *maze) init(r int, c int, s float64, seed int64, startPos mazeLocation, goalPos mazeLocation) {
 	m.grid = make([]byte, r*c)
 	m.rows = r
 	m.columns = c
 	m.goal = goalPos
 	m.start = startPos
+	if r < 1 || c < 1 || !m.inside(startPos) || !m.inside(goalPos) {
+		log.Fatalf("invalid maze: %d x %d with start %v and goal %v", r, c, startPos, goalPos)
+	}
 
-	rnd := rand.New(rand.NewSource(36))
+	rnd := rand.New(rand.NewSource(seed))
 
 	for x := 0; x < c; x++ {
 		for y := 0; y < r; y++ {
 			if rnd.Float64() < s {
-				m.grid[x+y*r] = BLOCKED
+				m.grid[m.index(mazeLocation{x, y})] = BLOCKED
 			} else {
-				m.grid[x+y*r] = EMPTY
+				m.grid[m.index(mazeLocation{x, y})] = EMPTY
 			}
 		}
 	}
-	m.grid[startPos.row+startPos.column*r] = START
-	m.grid[goalPos.row+goalPos.column*r] = GOAL
+	m.grid[m.index(startPos)] = START
+	m.grid[m.index(goalPos)] = GOAL
+}
+
+func (m maze) index(ml mazeLocation) int {
+	return ml.column + ml.row*m.columns
+}
+
+func (m maze) inside(ml mazeLocation) bool {
+	return ml.row >= 0 && ml.row < m.rows && ml.column >= 0 && ml.column < m.columns
 }
 
 func (m maze) print() {
 	for y := m.rows - 1; y >= 0; y-- {
 		for x := 0; x < m.columns; x++ {
-			fmt.Printf("%c", m.grid[x+y*m.rows])
+			fmt.Printf("%c", m.grid[m.index(mazeLocation{x, y})])
 		}
 		fmt.Printf("\n")
 	}
@@ -137,96 +87,76 @@ func (m maze) goalTest(ml mazeLocation) bool {
 
 func (m maze) successors(ml mazeLocation) []mazeLocation {
 	locations := []mazeLocation{}
-	if ml.row+1 < m.rows && m.grid[ml.column+(ml.row+1)*m.rows] != BLOCKED {
-		locations = append(locations, mazeLocation{ml.column, ml.row + 1})
-	}
-	if ml.row-1 >= 0 && m.grid[ml.column+(ml.row-1)*m.rows] != BLOCKED {
-		locations = append(locations, mazeLocation{ml.column, ml.row - 1})
-	}
-	if ml.column+1 < m.columns && m.grid[(ml.column+1)+ml.row*m.rows] != BLOCKED {
-		locations = append(locations, mazeLocation{ml.column + 1, ml.row})
+	for _, d := range []mazeLocation{{0, 1}, {0, -1}, {1, 0}, {-1, 0}} {
+		n := mazeLocation{ml.column + d.column, ml.row + d.row}
+		if m.passable(n) {
+			locations = append(locations, n)
+		}
 	}
-	if ml.column-1 >= 0 && m.grid[(ml.column-1)+ml.row*m.rows] != BLOCKED {
-		locations = append(locations, mazeLocation{ml.column - 1, ml.row})
+	if m.diagonal {
+		for _, d := range []mazeLocation{{1, 1}, {-1, 1}, {1, -1}, {-1, -1}} {
+			n := mazeLocation{ml.column + d.column, ml.row + d.row}
+			if m.passable(n) && m.diagonalAllowed(ml, n) {
+				locations = append(locations, n)
+			}
+		}
 	}
 	return locations
 }
 
+// mark draws the path over the maze, remembering the terrain it covers for clear.
 func (m *maze) mark(path []mazeLocation) {
+	if m.covered == nil {
+		m.covered = make(map[int]byte)
+	}
 	for _, val := range path {
-		m.grid[val.column+val.row*m.rows] = PATH
+		if _, ok := m.covered[m.index(val)]; !ok {
+			m.covered[m.index(val)] = m.grid[m.index(val)]
+		}
+		m.grid[m.index(val)] = PATH
 	}
-	m.grid[m.start.column+m.start.row*m.rows] = START
-	m.grid[m.goal.column+m.goal.row*m.rows] = GOAL
+	m.grid[m.index(m.start)] = START
+	m.grid[m.index(m.goal)] = GOAL
 }
 
 func (m *maze) clear(path []mazeLocation) {
 	for _, val := range path {
-		m.grid[val.column+val.row*m.rows] = EMPTY
-	}
-	m.grid[m.start.column+m.start.row*m.rows] = START
-	m.grid[m.goal.column+m.goal.row*m.rows] = GOAL
-}
-
-func dfs(m maze) node {
-	initial := m.start
-	frontier := stack{}
-	explored := make(map[mazeLocation]int)
-	frontier.push(node{initial, nil, 0, 0})
-	explored[initial] = 1
-
-	for !frontier.empty() {
-		currentNode := frontier.pop()
-		currentState := currentNode.state
-
-		if m.goalTest(currentState) {
-			return currentNode
-		}
-
-		for _, child := range m.successors(currentState) {
-			if _, ok := explored[child]; ok {
-				continue
-			}
-			explored[child] = 1
-			frontier.push(node{child, &currentNode, 0, 0})
+		m.grid[m.index(val)] = EMPTY
+		if cell, ok := m.covered[m.index(val)]; ok {
+			m.grid[m.index(val)] = cell
+			delete(m.covered, m.index(val))
 		}
 	}
-	return node{}
+	m.grid[m.index(m.start)] = START
+	m.grid[m.index(m.goal)] = GOAL
 }
 
-func nodeToPath(n node) []mazeLocation {
-	path := []mazeLocation{n.state}
-	for n.parent != nil {
-		n = *n.parent
-		path = append(path, n.state)
+// problem states the maze as a search problem; heuristic may be nil.
+func (m maze) problem(heuristic heuristicFn) search.Problem[mazeLocation] {
+	p := search.Problem[mazeLocation]{
+		Initial:    m.start,
+		Successors: m.successors,
+		Goal:       m.goalTest,
+		Cost:       m.stepCost,
+	}
+	if heuristic != nil {
+		p.Heuristic = func(ml mazeLocation) float64 { return heuristic(ml, m.goal) }
 	}
-	return path
+	if m.trace != nil {
+		p.OnExpand = func(ml mazeLocation) { m.trace.add(ml, true) }
+		p.OnGenerate = func(ml mazeLocation) { m.trace.add(ml, false) }
+	}
+	return p
 }
 
-func bfs(m maze) node {
-	initial := m.start
-	frontier := queue{}
-	explored := make(map[mazeLocation]int)
-	frontier.push(node{initial, nil, 0, 0})
-	explored[initial] = 1
-
-	for !frontier.empty() {
-		currentNode := frontier.pop()
-		currentState := currentNode.state
+type searchResult = search.Result[mazeLocation]
 
-		if m.goalTest(currentState) {
-			return currentNode
-		}
+func dfs(m maze) (searchResult, error) {
+	return search.DFS(m.problem(nil))
+}
 
-		for _, child := range m.successors(currentState) {
-			if _, ok := explored[child]; ok {
-				continue
-			}
-			explored[child] = 1
-			frontier.push(node{child, &currentNode, 0, 0})
-		}
-	}
-	return node{}
+func bfs(m maze) (searchResult, error) {
+	return search.BFS(m.problem(nil))
 }
 
 func manhattan(init, goal mazeLocation) float64 {
@@ -237,55 +167,178 @@ func manhattan(init, goal mazeLocation) float64 {
 
 type heuristicFn func(pos, goal mazeLocation) float64
 
-func astar(m maze, heuristic heuristicFn) node {
-	initial := m.start
-	frontier := PriorityQueue{}
-	explored := make(map[mazeLocation]float64)
-	heap.Init(&frontier)
+func astar(m maze, heuristic heuristicFn) (searchResult, error) {
+	return search.AStar(m.problem(heuristic))
+}
 
-	heap.Push(&frontier, node{initial, nil, 0, heuristic(initial, m.goal)})
+func uniformCost(m maze) (searchResult, error) {
+	return search.UniformCost(m.problem(nil))
+}
 
-	explored[initial] = 0.0
+func iddfs(m maze, transpositions bool) (searchResult, error) {
+	return search.IDDFS(m.problem(nil), transpositions)
+}
 
-	for !frontier.empty() {
-		currentNode := heap.Pop(&frontier).(node)
-		currentState := currentNode.state
+func idastar(m maze, heuristic heuristicFn, transpositions bool) (searchResult, error) {
+	return search.IDAStar(m.problem(heuristic), transpositions)
+}
 
-		if m.goalTest(currentState) {
-			return currentNode
-		}
+// bidirectional searches back from the goal with successors too: every move on the
+// grid can be reversed.
+func bidirectional(m maze) (searchResult, error) {
+	return search.BidirectionalBFS(m.problem(nil), m.goal)
+}
 
-		for _, child := range m.successors(currentState) {
-			newCost := currentNode.cost + 1.0
-			_, ok := explored[child]
-			if !ok || explored[child] > newCost {
-				explored[child] = newCost
-				heap.Push(&frontier, node{child, &currentNode, newCost, heuristic(child, m.goal)})
+func beam(m maze, heuristic heuristicFn, width int) (searchResult, error) {
+	return search.Beam(m.problem(heuristic), width)
+}
+
+func main() {
+	file := flag.String("file", "", "load the maze from a text file instead of generating it")
+	imageFile := flag.String("image", "", "load the maze from an image: dark pixels are walls, green is the start and red the goal")
+	threshold := flag.Int("threshold", 128, "gray level below which an image pixel is a wall")
+	solved := flag.String("solved", "solved.png", "where to write the image maze with the A* path")
+	save := flag.String("save", "", "save the maze to a text file")
+	rows := flag.Int("rows", 10, "rows of a generated maze")
+	columns := flag.Int("columns", 10, "columns of a generated maze")
+	density := flag.Float64("density", 0.2, "probability of a blocked cell in a generated maze")
+	seed := flag.Int64("seed", 36, "random seed of a generated maze")
+	generator := flag.String("generator", "", fmt.Sprintf("build a perfect maze with one of %v", generatorNames()))
+	braid := flag.Float64("braid", 0, "probability of opening a loop at each dead end of a perfect maze")
+	terrain := flag.Float64("terrain", 0, "probability of turning an empty cell into road, mud or water")
+	diagonal := flag.Bool("diagonal", false, "allow diagonal moves")
+	corners := flag.String("corners", "never", "diagonal corner cutting: never, one or always")
+	heuristicName := flag.String("heuristic", "", "A* heuristic: manhattan, euclidean, octile or chebyshev")
+	compare := flag.Int("compare", 0, "compare the solvers over this many random mazes")
+	algorithm := flag.String("algorithm", "all", "solver to run: all, dfs, bfs, astar, ucs, iddfs, idastar, bidirectional or beam")
+	beamWidth := flag.Int("beam", 10, "states kept per layer by beam search")
+	transpositions := flag.Bool("transpositions", false, "let iddfs and idastar prune states already reached, remembering every state they visit")
+	agents := flag.Int("agents", 0, "plan this many random agents with cooperative A* and CBS")
+	cbsNodes := flag.Int("cbs-nodes", 10000, "constraint sets CBS may try before giving up")
+	render := flag.String("render", "", "write a PNG and SVG heatmap and a GIF animation of each solver to this directory")
+	flag.Parse()
+	if *threshold < 0 || *threshold > 255 {
+		log.Fatalf("threshold %d is not a gray level between 0 and 255", *threshold)
+	}
+
+	policy, ok := cornerPolicies[*corners]
+	if !ok {
+		log.Fatalf("unknown corner policy %q", *corners)
+	}
+	// Terrain and moves apply to loaded mazes as well as generated ones.
+	configure := func(m *maze, seed int64) {
+		if *terrain > 0 {
+			m.scatterTerrain(*terrain, seed)
+		}
+		m.diagonal = *diagonal
+		m.corners = policy
+	}
+	newMaze := func(seed int64) (maze, error) {
+		m := maze{}
+		if *generator != "" {
+			if err := m.generate(*rows, *columns, *generator, *braid, seed); err != nil {
+				return m, err
 			}
+		} else {
+			m.init(*rows, *columns, *density, seed, mazeLocation{0, 0}, mazeLocation{*columns - 1, *rows - 1})
 		}
+		configure(&m, seed)
+		return m, nil
+	}
+	newSolvers := func(m maze) ([]namedSolver, error) {
+		heuristic, err := m.heuristic(*heuristicName)
+		if err != nil {
+			return nil, err
+		}
+		solvers := mazeSolvers(heuristic, *beamWidth, *transpositions)
+		if *algorithm != "all" {
+			if solvers = selectSolver(solvers, *algorithm); solvers == nil {
+				return nil, fmt.Errorf("unknown algorithm %q", *algorithm)
+			}
+		}
+		return solvers, nil
+	}
+	if *compare > 0 {
+		if err := compareSolvers(os.Stdout, newMaze, newSolvers, *compare, *seed); err != nil {
+			log.Fatal(err)
+		}
+		return
 	}
-	return node{}
-}
 
-func main() {
-	maze := maze{}
-	maze.init(10, 10, 0.2, mazeLocation{0, 0}, mazeLocation{9, 9})
-	maze.print()
-	solution := dfs(maze)
-	path := nodeToPath(solution)
-	maze.mark(path)
-	fmt.Println("-------------------")
-	maze.print()
-	maze.clear(path)
-	fmt.Println("-------------------")
-	solution2 := bfs(maze)
-	path2 := nodeToPath(solution2)
-	maze.mark(path2)
-	maze.print()
-	maze.clear(path2)
-	fmt.Println("-------------------")
-	solution3 := astar(maze, manhattan)
-	path3 := nodeToPath(solution3)
-	maze.mark(path3)
+	var maze maze
+	var img image.Image
+	var err error
+	if *file != "" {
+		if err := maze.loadFile(*file); err != nil {
+			log.Fatal(err)
+		}
+		configure(&maze, *seed)
+	} else if *imageFile != "" {
+		if img, err = readImage(*imageFile); err != nil {
+			log.Fatal(err)
+		}
+		if err := maze.loadImage(img, uint8(*threshold)); err != nil {
+			log.Fatal(err)
+		}
+		configure(&maze, *seed)
+	} else if maze, err = newMaze(*seed); err != nil {
+		log.Fatal(err)
+	}
+	solvers, err := newSolvers(maze)
+	if err != nil {
+		log.Fatal(err)
+	}
+	if *save != "" {
+		if err := maze.saveFile(*save); err != nil {
+			log.Fatal(err)
+		}
+	}
+	if *agents > 0 {
+		maze.print()
+		if err := compareMultiAgent(os.Stdout, maze, *agents, *seed, *cbsNodes); err != nil {
+			log.Fatal(err)
+		}
+		return
+	}
+	if img != nil {
+		heuristic, err := maze.heuristic(*heuristicName)
+		if err != nil {
+			log.Fatal(err)
+		}
+		result, err := astar(maze, heuristic)
+		if err != nil {
+			log.Fatal(err)
+		}
+		if err := maze.writeSolvedImage(*solved, img, result.Path); err != nil {
+			log.Fatal(err)
+		}
+		fmt.Printf("%d x %d maze, astar: %v\n", maze.columns, maze.rows, result)
+		return
+	}
+	if *render != "" {
+		for _, solver := range solvers {
+			if err := renderSolver(*render, solver, maze); err != nil {
+				log.Fatal(err)
+			}
+		}
+	}
 	maze.print()
+	summaries := []solverSummary{}
+	for _, solver := range solvers {
+		fmt.Println("-------------------")
+		result, err := solver.solve(maze)
+		if err != nil {
+			fmt.Printf("%s: %v\n", solver.name, err)
+			continue
+		}
+		maze.mark(result.Path)
+		maze.print()
+		maze.clear(result.Path)
+		fmt.Printf("%s: %v\n", solver.name, result)
+		summaries = append(summaries, solverSummary{solver.name, 1, 0, result.Cost, result.Expanded, result.PeakFrontier, result.Elapsed})
+	}
+	if len(summaries) > 1 {
+		fmt.Println("-------------------")
+		printSummaries(os.Stdout, summaries, false)
+	}
 }
@@ -0,0 +1,104 @@
+package main
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+)
+
+var errMalformedMaze = errors.New("malformed maze")
+
+// load reads a maze drawn with the EMPTY, BLOCKED, START, GOAL and terrain characters, one line
+// per row with the top row first, as print shows it. PATH cells are read as EMPTY. Every
+// row must be as wide as the first one; empty lines at the end of the file are ignored.
+func (m *maze) load(r io.Reader) error {
+	lines := []string{}
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 64*1024), 64*1024*1024)
+	for scanner.Scan() {
+		lines = append(lines, strings.TrimRight(scanner.Text(), "\r"))
+	}
+	if err := scanner.Err(); err != nil {
+		return err
+	}
+	for len(lines) > 0 && lines[len(lines)-1] == "" {
+		lines = lines[:len(lines)-1]
+	}
+	if len(lines) == 0 {
+		return fmt.Errorf("%w: no rows", errMalformedMaze)
+	}
+	columns := len(lines[0])
+	for l, line := range lines {
+		if len(line) != columns {
+			return fmt.Errorf("%w: line %d is %d cells wide, expected %d", errMalformedMaze, l+1, len(line), columns)
+		}
+	}
+
+	loaded := maze{rows: len(lines), columns: columns, grid: make([]byte, len(lines)*columns)}
+	starts, goals := 0, 0
+	for l, line := range lines {
+		row := len(lines) - 1 - l
+		for column := 0; column < columns; column++ {
+			cell := line[column]
+			ml := mazeLocation{column, row}
+			switch cell {
+			case EMPTY, BLOCKED, ROAD, MUD, WATER:
+			case PATH:
+				cell = EMPTY
+			case START:
+				loaded.start = ml
+				starts++
+			case GOAL:
+				loaded.goal = ml
+				goals++
+			default:
+				return fmt.Errorf("%w: line %d, column %d: unknown cell %q", errMalformedMaze, l+1, column+1, cell)
+			}
+			loaded.grid[loaded.index(ml)] = cell
+		}
+	}
+	if starts != 1 {
+		return fmt.Errorf("%w: expected one start cell %q, found %d", errMalformedMaze, START, starts)
+	}
+	if goals != 1 {
+		return fmt.Errorf("%w: expected one goal cell %q, found %d", errMalformedMaze, GOAL, goals)
+	}
+	*m = loaded
+	return nil
+}
+
+func (m maze) save(w io.Writer) error {
+	bw := bufio.NewWriter(w)
+	for y := m.rows - 1; y >= 0; y-- {
+		bw.Write(m.grid[y*m.columns : (y+1)*m.columns])
+		bw.WriteByte('\n')
+	}
+	return bw.Flush()
+}
+
+func (m *maze) loadFile(name string) error {
+	f, err := os.Open(name)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	if err := m.load(f); err != nil {
+		return fmt.Errorf("%s: %w", name, err)
+	}
+	return nil
+}
+
+func (m maze) saveFile(name string) error {
+	f, err := os.Create(name)
+	if err != nil {
+		return err
+	}
+	if err := m.save(f); err != nil {
+		f.Close()
+		return err
+	}
+	return f.Close()
+}
@@ -0,0 +1,428 @@
+package main
+
+import (
+	"container/heap"
+	"errors"
+	"fmt"
+	"io"
+	"math/rand"
+	"time"
+
+	"github.com/arlima/problemas_classicos_CC/search"
+)
+
+var errNoPlan = errors.New("no conflict-free plan")
+
+type agent struct {
+	start mazeLocation
+	goal  mazeLocation
+}
+
+// timedPath is where an agent is at each time step; after the last step it waits on
+// its goal for ever.
+type timedPath []mazeLocation
+
+func (p timedPath) at(t int) mazeLocation {
+	if t >= len(p) {
+		return p[len(p)-1]
+	}
+	return p[t]
+}
+
+func (p timedPath) cost() int {
+	return len(p) - 1
+}
+
+type timedLocation struct {
+	mazeLocation
+	time int
+}
+
+type multiPlan struct {
+	paths      []timedPath
+	makespan   int
+	sumOfCosts int
+	expanded   int
+}
+
+func (mp *multiPlan) finish() {
+	mp.makespan, mp.sumOfCosts = 0, 0
+	for _, p := range mp.paths {
+		mp.makespan = max(mp.makespan, p.cost())
+		mp.sumOfCosts += p.cost()
+	}
+}
+
+func (mp multiPlan) String() string {
+	return fmt.Sprintf("makespan %d, sum of costs %d, %d states expanded", mp.makespan, mp.sumOfCosts, mp.expanded)
+}
+
+// randomAgents places count agents on distinct open cells with distinct goals that
+// they can reach.
+func (m maze) randomAgents(count int, seed int64) ([]agent, error) {
+	open := []mazeLocation{}
+	for y := 0; y < m.rows; y++ {
+		for x := 0; x < m.columns; x++ {
+			if m.passable(mazeLocation{x, y}) {
+				open = append(open, mazeLocation{x, y})
+			}
+		}
+	}
+	if 2*count > len(open) {
+		return nil, fmt.Errorf("%d agents do not fit in %d open cells", count, len(open))
+	}
+	rnd := rand.New(rand.NewSource(seed))
+	rnd.Shuffle(len(open), func(i, j int) { open[i], open[j] = open[j], open[i] })
+	agents := []agent{}
+	for _, start := range open[:count] {
+		dist := m.distances(start)
+		for i := count; i < len(open); i++ {
+			if _, ok := dist[open[i]]; ok {
+				agents = append(agents, agent{start, open[i]})
+				open[i] = open[len(open)-1]
+				open = open[:len(open)-1]
+				break
+			}
+		}
+	}
+	if len(agents) < count {
+		return nil, fmt.Errorf("found reachable goals for only %d of %d agents", len(agents), count)
+	}
+	return agents, nil
+}
+
+// distances counts the steps from every cell that can reach goal; moves on the grid are
+// reversible, so a breadth-first search from the goal over successors finds them.
+func (m maze) distances(goal mazeLocation) map[mazeLocation]int {
+	dist := map[mazeLocation]int{goal: 0}
+	frontier := []mazeLocation{goal}
+	for len(frontier) > 0 {
+		current := frontier[0]
+		frontier = frontier[1:]
+		for _, n := range m.successors(current) {
+			if _, ok := dist[n]; !ok {
+				dist[n] = dist[current] + 1
+				frontier = append(frontier, n)
+			}
+		}
+	}
+	return dist
+}
+
+// spaceTimeRules restrict a single agent's moves: blocked forbids arriving at to from
+// from at time t, and lastBlocked is the last time anyone else needs a cell, after which
+// the agent may stop there.
+type spaceTimeRules struct {
+	blocked     func(from, to mazeLocation, t int) bool
+	lastBlocked func(cell mazeLocation) int
+	horizon     int
+}
+
+// planAgent runs A* over (cell, time) states where the agent may also wait in place.
+// Every step takes one time unit and the heuristic is the true distance ignoring the
+// other agents.
+func (m maze) planAgent(a agent, dist map[mazeLocation]int, rules spaceTimeRules) (timedPath, int, error) {
+	if _, ok := dist[a.start]; !ok {
+		return nil, 0, fmt.Errorf("%w: agent at %v cannot reach %v", errNoPlan, a.start, a.goal)
+	}
+	result, err := search.AStar(search.Problem[timedLocation]{
+		Initial: timedLocation{a.start, 0},
+		Successors: func(s timedLocation) []timedLocation {
+			if s.time >= rules.horizon {
+				return nil
+			}
+			next := []timedLocation{}
+			for _, n := range append(m.successors(s.mazeLocation), s.mazeLocation) {
+				if _, ok := dist[n]; ok && !rules.blocked(s.mazeLocation, n, s.time+1) {
+					next = append(next, timedLocation{n, s.time + 1})
+				}
+			}
+			return next
+		},
+		Goal: func(s timedLocation) bool {
+			return s.mazeLocation == a.goal && s.time > rules.lastBlocked(a.goal)
+		},
+		Heuristic: func(s timedLocation) float64 { return float64(dist[s.mazeLocation]) },
+	})
+	if err != nil {
+		return nil, result.Expanded, fmt.Errorf("%w: agent from %v to %v", errNoPlan, a.start, a.goal)
+	}
+	path := make(timedPath, len(result.Path))
+	for i, s := range result.Path {
+		path[i] = s.mazeLocation
+	}
+	return path, result.Expanded, nil
+}
+
+func validAgents(agents []agent) error {
+	starts, goals := map[mazeLocation]bool{}, map[mazeLocation]bool{}
+	for _, a := range agents {
+		if starts[a.start] || goals[a.goal] {
+			return fmt.Errorf("%w: agents share a start or a goal", errNoPlan)
+		}
+		starts[a.start], goals[a.goal] = true, true
+	}
+	return nil
+}
+
+// cooperativeAStar plans the agents one after the other, each avoiding the cells and
+// moves reserved by those before it. It is fast but incomplete: an early agent may park
+// where a later one must pass.
+func cooperativeAStar(m maze, agents []agent) (multiPlan, error) {
+	plan := multiPlan{}
+	if err := validAgents(agents); err != nil {
+		return plan, err
+	}
+	type move struct {
+		from, to mazeLocation
+		time     int
+	}
+	vertices := map[timedLocation]bool{}
+	moves := map[move]bool{}
+	parked := map[mazeLocation]int{}
+	last := map[mazeLocation]int{}
+	horizon := len(m.grid)
+	for _, a := range agents {
+		rules := spaceTimeRules{
+			blocked: func(from, to mazeLocation, t int) bool {
+				if p, ok := parked[to]; ok && t >= p {
+					return true
+				}
+				return vertices[timedLocation{to, t}] || moves[move{to, from, t}]
+			},
+			lastBlocked: func(cell mazeLocation) int {
+				if _, ok := parked[cell]; ok {
+					return horizon
+				}
+				if t, ok := last[cell]; ok {
+					return t
+				}
+				return -1
+			},
+			horizon: horizon,
+		}
+		path, expanded, err := m.planAgent(a, m.distances(a.goal), rules)
+		plan.expanded += expanded
+		if err != nil {
+			return plan, err
+		}
+		for t, cell := range path {
+			vertices[timedLocation{cell, t}] = true
+			last[cell] = max(last[cell], t)
+			if t > 0 {
+				moves[move{path[t-1], cell, t}] = true
+			}
+		}
+		parked[a.goal] = path.cost()
+		horizon = max(horizon, len(m.grid)+len(path))
+		plan.paths = append(plan.paths, path)
+	}
+	plan.finish()
+	return plan, nil
+}
+
+// conflict is two agents in the same cell at a time step or, when to is set, swapping
+// cells between time-1 and time.
+type conflict struct {
+	a, b     int
+	cell, to mazeLocation
+	time     int
+	swap     bool
+}
+
+func (c conflict) String() string {
+	if c.swap {
+		return fmt.Sprintf("agents %d and %d swap %v and %v at time %d", c.a, c.b, c.cell, c.to, c.time)
+	}
+	return fmt.Sprintf("agents %d and %d meet at %v at time %d", c.a, c.b, c.cell, c.time)
+}
+
+// conflicts lists the conflicts between the paths in time order.
+func conflicts(paths []timedPath) []conflict {
+	found := []conflict{}
+	makespan := 0
+	for _, p := range paths {
+		makespan = max(makespan, p.cost())
+	}
+	for t := 0; t <= makespan; t++ {
+		for i := range paths {
+			for j := i + 1; j < len(paths); j++ {
+				if paths[i].at(t) == paths[j].at(t) {
+					found = append(found, conflict{a: i, b: j, cell: paths[i].at(t), time: t})
+				} else if t > 0 && paths[i].at(t-1) == paths[j].at(t) && paths[j].at(t-1) == paths[i].at(t) {
+					found = append(found, conflict{a: i, b: j, cell: paths[i].at(t - 1), to: paths[i].at(t), time: t, swap: true})
+				}
+			}
+		}
+	}
+	return found
+}
+
+// constraint forbids agent from arriving at cell at time, or, for a move, from stepping
+// from into cell at time.
+type constraint struct {
+	agent int
+	from  mazeLocation
+	cell  mazeLocation
+	time  int
+	move  bool
+}
+
+type cbsNode struct {
+	constraints []constraint
+	paths       []timedPath
+	cost        int
+	conflicts   []conflict
+}
+
+type cbsQueue []*cbsNode
+
+func (q cbsQueue) Len() int { return len(q) }
+func (q cbsQueue) Less(i, j int) bool {
+	if q[i].cost != q[j].cost {
+		return q[i].cost < q[j].cost
+	}
+	return len(q[i].conflicts) < len(q[j].conflicts)
+}
+func (q cbsQueue) Swap(i, j int) { q[i], q[j] = q[j], q[i] }
+func (q *cbsQueue) Push(x any)   { *q = append(*q, x.(*cbsNode)) }
+func (q *cbsQueue) Pop() any {
+	old := *q
+	item := old[len(old)-1]
+	*q = old[:len(old)-1]
+	return item
+}
+
+func (m maze) planConstrained(a agent, index int, dist map[mazeLocation]int, constraints []constraint) (timedPath, int, error) {
+	vertices := map[timedLocation]bool{}
+	type move struct {
+		from, to mazeLocation
+		time     int
+	}
+	moves := map[move]bool{}
+	last := map[mazeLocation]int{}
+	latest := 0
+	for _, c := range constraints {
+		if c.agent != index {
+			continue
+		}
+		latest = max(latest, c.time)
+		if c.move {
+			moves[move{c.from, c.cell, c.time}] = true
+			continue
+		}
+		vertices[timedLocation{c.cell, c.time}] = true
+		if t, ok := last[c.cell]; !ok || c.time > t {
+			last[c.cell] = c.time
+		}
+	}
+	return m.planAgent(a, dist, spaceTimeRules{
+		blocked: func(from, to mazeLocation, t int) bool {
+			return vertices[timedLocation{to, t}] || moves[move{from, to, t}]
+		},
+		lastBlocked: func(cell mazeLocation) int {
+			if t, ok := last[cell]; ok {
+				return t
+			}
+			return -1
+		},
+		horizon: latest + len(m.grid),
+	})
+}
+
+// conflictBasedSearch finds a plan with the least sum of costs. Its high level searches
+// a tree of constraint sets: each node plans every agent alone under its constraints
+// and splits on the first conflict, forbidding it to one agent or the other. Among
+// equally cheap nodes the one with fewer conflicts goes first. It gives up after
+// maxNodes high-level nodes.
+func conflictBasedSearch(m maze, agents []agent, maxNodes int) (multiPlan, error) {
+	plan := multiPlan{}
+	if err := validAgents(agents); err != nil {
+		return plan, err
+	}
+	dists := make([]map[mazeLocation]int, len(agents))
+	root := &cbsNode{}
+	for i, a := range agents {
+		dists[i] = m.distances(a.goal)
+		path, expanded, err := m.planConstrained(a, i, dists[i], nil)
+		plan.expanded += expanded
+		if err != nil {
+			return plan, err
+		}
+		root.paths = append(root.paths, path)
+		root.cost += path.cost()
+	}
+	root.conflicts = conflicts(root.paths)
+
+	open := &cbsQueue{root}
+	for nodes := 0; open.Len() > 0 && nodes < maxNodes; nodes++ {
+		current := heap.Pop(open).(*cbsNode)
+		if len(current.conflicts) == 0 {
+			plan.paths = current.paths
+			plan.finish()
+			return plan, nil
+		}
+		c := current.conflicts[0]
+		for _, split := range []constraint{
+			{agent: c.a, cell: c.cell, time: c.time},
+			{agent: c.b, cell: c.cell, time: c.time},
+		} {
+			if c.swap {
+				split.move = true
+				if split.agent == c.a {
+					split.from, split.cell = c.cell, c.to
+				} else {
+					split.from, split.cell = c.to, c.cell
+				}
+			}
+			child := &cbsNode{
+				constraints: append(append([]constraint{}, current.constraints...), split),
+				paths:       append([]timedPath{}, current.paths...),
+			}
+			path, expanded, err := m.planConstrained(agents[split.agent], split.agent, dists[split.agent], child.constraints)
+			plan.expanded += expanded
+			if err != nil {
+				continue
+			}
+			child.paths[split.agent] = path
+			for _, p := range child.paths {
+				child.cost += p.cost()
+			}
+			child.conflicts = conflicts(child.paths)
+			heap.Push(open, child)
+		}
+	}
+	return plan, fmt.Errorf("%w: gave up after %d constraint sets", errNoPlan, maxNodes)
+}
+
+// compareMultiAgent places count random agents on the maze and prints the plans of
+// cooperative A* and CBS.
+func compareMultiAgent(w io.Writer, m maze, count int, seed int64, maxNodes int) error {
+	agents, err := m.randomAgents(count, seed)
+	if err != nil {
+		return err
+	}
+	for i, a := range agents {
+		fmt.Fprintf(w, "agent %d: %v -> %v\n", i, a.start, a.goal)
+	}
+	planners := []struct {
+		name string
+		plan func() (multiPlan, error)
+	}{
+		{"cooperative A*", func() (multiPlan, error) { return cooperativeAStar(m, agents) }},
+		{"CBS", func() (multiPlan, error) { return conflictBasedSearch(m, agents, maxNodes) }},
+	}
+	for _, planner := range planners {
+		started := time.Now()
+		plan, err := planner.plan()
+		if err != nil {
+			fmt.Fprintf(w, "%s: %v\n", planner.name, err)
+			continue
+		}
+		fmt.Fprintf(w, "%s: %v, %v\n", planner.name, plan, time.Since(started))
+		for i, p := range plan.paths {
+			fmt.Fprintf(w, "  agent %d: %v\n", i, p)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,273 @@
+package main
+
+import (
+	"bufio"
+	"fmt"
+	"image"
+	"image/color"
+	"image/draw"
+	"image/gif"
+	"image/png"
+	"io"
+	"os"
+	"path/filepath"
+)
+
+// mazeTrace records, in order, the cells a search generated and expanded.
+type mazeTrace struct {
+	events []traceEvent
+}
+
+type traceEvent struct {
+	location mazeLocation
+	expanded bool
+}
+
+func (t *mazeTrace) add(ml mazeLocation, expanded bool) {
+	t.events = append(t.events, traceEvent{ml, expanded})
+}
+
+// expansionOrder numbers cells by their first expansion.
+func (t *mazeTrace) expansionOrder() (map[mazeLocation]int, int) {
+	order := map[mazeLocation]int{}
+	for _, e := range t.events {
+		if _, ok := order[e.location]; e.expanded && !ok {
+			order[e.location] = len(order)
+		}
+	}
+	return order, len(order)
+}
+
+var (
+	wallColor  = color.RGBA{40, 40, 40, 255}
+	emptyColor = color.RGBA{255, 255, 255, 255}
+	startColor = color.RGBA{0, 160, 0, 255}
+	goalColor  = color.RGBA{200, 0, 0, 255}
+	pathColor  = color.RGBA{255, 0, 255, 255}
+)
+
+var terrainColors = map[byte]color.RGBA{
+	ROAD:  {200, 200, 200, 255},
+	MUD:   {150, 110, 60, 255},
+	WATER: {120, 170, 230, 255},
+}
+
+// heatColor runs from blue for the first expansions through yellow to red for the last.
+func heatColor(t float64) color.RGBA {
+	stops := []color.RGBA{{49, 54, 149, 255}, {255, 255, 191, 255}, {165, 0, 38, 255}}
+	if t <= 0.5 {
+		return blend(stops[0], stops[1], t*2)
+	}
+	return blend(stops[1], stops[2], (t-0.5)*2)
+}
+
+func blend(a color.RGBA, b color.RGBA, t float64) color.RGBA {
+	mix := func(x, y uint8) uint8 { return uint8(float64(x) + (float64(y)-float64(x))*t) }
+	return color.RGBA{mix(a.R, b.R), mix(a.G, b.G), mix(a.B, b.B), 255}
+}
+
+func (m maze) cellColor(ml mazeLocation) color.RGBA {
+	switch cell := m.grid[m.index(ml)]; cell {
+	case BLOCKED:
+		return wallColor
+	case START:
+		return startColor
+	case GOAL:
+		return goalColor
+	default:
+		if c, ok := terrainColors[cell]; ok {
+			return c
+		}
+	}
+	return emptyColor
+}
+
+// cellRect is the square of a cell in an image, with row 0 at the bottom as in print.
+func (m maze) cellRect(ml mazeLocation, size int) image.Rectangle {
+	x, y := ml.column*size, (m.rows-1-ml.row)*size
+	return image.Rect(x, y, x+size, y+size)
+}
+
+func cellCenter(r image.Rectangle) image.Point {
+	return image.Pt((r.Min.X+r.Max.X)/2, (r.Min.Y+r.Max.Y)/2)
+}
+
+// drawLine paints a thick segment by stamping squares along it.
+func drawLine(img draw.Image, from image.Point, to image.Point, width int, c color.Color) {
+	dx, dy := to.X-from.X, to.Y-from.Y
+	steps := max(abs(dx), abs(dy), 1)
+	for i := 0; i <= steps; i++ {
+		x, y := from.X+dx*i/steps, from.Y+dy*i/steps
+		draw.Draw(img, image.Rect(x-width/2, y-width/2, x+width-width/2, y+width-width/2), image.NewUniform(c), image.Point{}, draw.Src)
+	}
+}
+
+func abs(x int) int {
+	if x < 0 {
+		return -x
+	}
+	return x
+}
+
+// renderImage draws the maze with size pixel cells, shading the cells the trace expanded
+// by their order and drawing the path over them. trace and path may be nil.
+func (m maze) renderImage(size int, trace *mazeTrace, path []mazeLocation) *image.RGBA {
+	img := image.NewRGBA(image.Rect(0, 0, m.columns*size, m.rows*size))
+	order, total := map[mazeLocation]int{}, 1
+	if trace != nil {
+		order, total = trace.expansionOrder()
+	}
+	for y := 0; y < m.rows; y++ {
+		for x := 0; x < m.columns; x++ {
+			ml := mazeLocation{x, y}
+			c := m.cellColor(ml)
+			if i, ok := order[ml]; ok && ml != m.start && ml != m.goal {
+				c = heatColor(float64(i) / float64(max(total-1, 1)))
+			}
+			draw.Draw(img, m.cellRect(ml, size), image.NewUniform(c), image.Point{}, draw.Src)
+		}
+	}
+	for i := 1; i < len(path); i++ {
+		drawLine(img, cellCenter(m.cellRect(path[i-1], size)), cellCenter(m.cellRect(path[i], size)), max(size/4, 1), pathColor)
+	}
+	return img
+}
+
+func (m maze) writeSVG(w io.Writer, size int, trace *mazeTrace, path []mazeLocation) error {
+	bw := bufio.NewWriter(w)
+	fmt.Fprintf(bw, `<svg xmlns="http://www.w3.org/2000/svg" width="%d" height="%d" shape-rendering="crispEdges">`+"\n",
+		m.columns*size, m.rows*size)
+	fmt.Fprintf(bw, `<rect width="100%%" height="100%%" fill="%s"/>`+"\n", hex(emptyColor))
+	order, total := map[mazeLocation]int{}, 1
+	if trace != nil {
+		order, total = trace.expansionOrder()
+	}
+	for y := 0; y < m.rows; y++ {
+		for x := 0; x < m.columns; x++ {
+			ml := mazeLocation{x, y}
+			c := m.cellColor(ml)
+			if i, ok := order[ml]; ok && ml != m.start && ml != m.goal {
+				c = heatColor(float64(i) / float64(max(total-1, 1)))
+			}
+			if c == emptyColor {
+				continue
+			}
+			r := m.cellRect(ml, size)
+			fmt.Fprintf(bw, `<rect x="%d" y="%d" width="%d" height="%d" fill="%s"/>`+"\n", r.Min.X, r.Min.Y, size, size, hex(c))
+		}
+	}
+	if len(path) > 1 {
+		fmt.Fprintf(bw, `<polyline fill="none" stroke="%s" stroke-width="%d" stroke-linejoin="round" points="`, hex(pathColor), max(size/4, 1))
+		for _, ml := range path {
+			p := cellCenter(m.cellRect(ml, size))
+			fmt.Fprintf(bw, "%d,%d ", p.X, p.Y)
+		}
+		fmt.Fprintln(bw, `"/>`)
+	}
+	fmt.Fprintln(bw, "</svg>")
+	return bw.Flush()
+}
+
+func hex(c color.RGBA) string {
+	return fmt.Sprintf("#%02x%02x%02x", c.R, c.G, c.B)
+}
+
+// writeGIF animates the trace: each frame adds the next events, showing the frontier
+// in orange and the expanded cells in blue, and the last frame adds the path. Long
+// traces are grouped so the animation has at most maxFrames frames.
+func (m maze) writeGIF(w io.Writer, size int, trace *mazeTrace, path []mazeLocation, maxFrames int) error {
+	frontierColor := color.RGBA{255, 150, 0, 255}
+	expandedColor := color.RGBA{110, 160, 220, 255}
+	palette := color.Palette{color.Transparent, emptyColor, wallColor, startColor, goalColor, pathColor, frontierColor, expandedColor}
+	for _, c := range terrainColors {
+		palette = append(palette, c)
+	}
+
+	base := image.NewPaletted(image.Rect(0, 0, m.columns*size, m.rows*size), palette)
+	for y := 0; y < m.rows; y++ {
+		for x := 0; x < m.columns; x++ {
+			draw.Draw(base, m.cellRect(mazeLocation{x, y}, size), image.NewUniform(m.cellColor(mazeLocation{x, y})), image.Point{}, draw.Src)
+		}
+	}
+	// After the first frame, each frame only covers the rectangle that changed since the
+	// previous one and leaves the unchanged pixels transparent, which keeps the file small.
+	anim := gif.GIF{}
+	dirty, changed := base.Rect, []image.Rectangle{base.Rect}
+	addFrame := func(delay int) {
+		if len(changed) == 0 {
+			anim.Delay[len(anim.Delay)-1] += delay
+			return
+		}
+		frame := image.NewPaletted(dirty, palette)
+		for _, r := range changed {
+			draw.Draw(frame, r, base, r.Min, draw.Src)
+		}
+		anim.Image = append(anim.Image, frame)
+		anim.Delay = append(anim.Delay, delay)
+		anim.Disposal = append(anim.Disposal, gif.DisposalNone)
+		dirty, changed = image.Rectangle{}, nil
+	}
+	paint := func(r image.Rectangle, c color.Color) {
+		draw.Draw(base, r, image.NewUniform(c), image.Point{}, draw.Src)
+		dirty = dirty.Union(r)
+		changed = append(changed, r)
+	}
+	addFrame(4)
+
+	perFrame := max((len(trace.events)+maxFrames-1)/maxFrames, 1)
+	seen := map[mazeLocation]bool{}
+	for i, e := range trace.events {
+		expanded, ok := seen[e.location]
+		if (!ok || e.expanded && !expanded) && e.location != m.start && e.location != m.goal {
+			seen[e.location] = e.expanded
+			c := frontierColor
+			if e.expanded {
+				c = expandedColor
+			}
+			paint(m.cellRect(e.location, size), c)
+		}
+		if (i+1)%perFrame == 0 {
+			addFrame(4)
+		}
+	}
+	for i := 1; i < len(path); i++ {
+		drawLine(base, cellCenter(m.cellRect(path[i-1], size)), cellCenter(m.cellRect(path[i], size)), max(size/4, 1), pathColor)
+		for _, ml := range path[i-1 : i+1] {
+			dirty = dirty.Union(m.cellRect(ml, size))
+			changed = append(changed, m.cellRect(ml, size))
+		}
+	}
+	addFrame(300)
+	return gif.EncodeAll(w, &anim)
+}
+
+func writeFile(name string, write func(io.Writer) error) error {
+	f, err := os.Create(name)
+	if err != nil {
+		return err
+	}
+	if err := write(f); err != nil {
+		f.Close()
+		return err
+	}
+	return f.Close()
+}
+
+// renderSolver runs solver with a trace and writes name.png, name.svg and name.gif to dir.
+func renderSolver(dir string, solver namedSolver, m maze) error {
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return err
+	}
+	m.trace = &mazeTrace{}
+	result, _ := solver.solve(m)
+	size := max(4, min(20, 1000/max(m.rows, m.columns)))
+	base := filepath.Join(dir, solver.name)
+	img := m.renderImage(size, m.trace, result.Path)
+	if err := writeFile(base+".png", func(w io.Writer) error { return png.Encode(w, img) }); err != nil {
+		return err
+	}
+	if err := writeFile(base+".svg", func(w io.Writer) error { return m.writeSVG(w, size, m.trace, result.Path) }); err != nil {
+		return err
+	}
+	return writeFile(base+".gif", func(w io.Writer) error { return m.writeGIF(w, size, m.trace, result.Path, 150) })
+}
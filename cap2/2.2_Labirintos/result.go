@@ -0,0 +1,125 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"math/rand"
+	"text/tabwriter"
+	"time"
+
+	"github.com/arlima/problemas_classicos_CC/search"
+)
+
+type solver func(m maze) (searchResult, error)
+
+type namedSolver struct {
+	name  string
+	solve solver
+}
+
+// mazeSolvers lists every search strategy for the maze; astar, idastar and beam use the
+// given heuristic.
+func mazeSolvers(heuristic heuristicFn, beamWidth int, transpositions bool) []namedSolver {
+	return []namedSolver{
+		{"dfs", dfs},
+		{"bfs", bfs},
+		{"astar", func(m maze) (searchResult, error) { return astar(m, heuristic) }},
+		{"ucs", uniformCost},
+		{"iddfs", func(m maze) (searchResult, error) { return iddfs(m, transpositions) }},
+		{"idastar", func(m maze) (searchResult, error) { return idastar(m, heuristic, transpositions) }},
+		{"bidirectional", bidirectional},
+		{"beam", func(m maze) (searchResult, error) { return beam(m, heuristic, beamWidth) }},
+	}
+}
+
+func selectSolver(solvers []namedSolver, name string) []namedSolver {
+	for _, s := range solvers {
+		if s.name == name {
+			return []namedSolver{s}
+		}
+	}
+	return nil
+}
+
+type solverSummary struct {
+	name         string
+	solved       int
+	optimal      int
+	cost         float64
+	expanded     int
+	peakFrontier int
+	elapsed      time.Duration
+}
+
+// A mazeFactory builds the maze for a seed the same way main does for a single maze.
+type mazeFactory func(seed int64) (maze, error)
+
+// compareSolvers runs the solvers chosen for each maze over count mazes from newMaze and
+// prints their averages over the solvable ones. A path is optimal when its cost matches
+// the uniform-cost search.
+func compareSolvers(w io.Writer, newMaze mazeFactory, newSolvers func(m maze) ([]namedSolver, error), count int, seed int64) error {
+	summaries := []solverSummary{}
+	rnd := rand.New(rand.NewSource(seed))
+	unsolvable := 0
+	var m maze
+	for i := 0; i < count; i++ {
+		var err error
+		if m, err = newMaze(rnd.Int63()); err != nil {
+			return err
+		}
+		solvers, err := newSolvers(m)
+		if err != nil {
+			return err
+		}
+		if len(summaries) == 0 {
+			for _, s := range solvers {
+				summaries = append(summaries, solverSummary{name: s.name})
+			}
+		}
+		best, err := uniformCost(m)
+		if errors.Is(err, search.ErrUnreachable) {
+			unsolvable++
+			continue
+		}
+		for j, solver := range solvers {
+			r, err := solver.solve(m)
+			if err != nil {
+				continue
+			}
+			s := &summaries[j]
+			s.solved++
+			if math.Abs(r.Cost-best.Cost) < 1e-9 {
+				s.optimal++
+			}
+			s.cost += r.Cost
+			s.expanded += r.Expanded
+			s.peakFrontier += r.PeakFrontier
+			s.elapsed += r.Elapsed
+		}
+	}
+
+	fmt.Fprintf(w, "%d random %d x %d mazes, %d unsolvable\n", count, m.columns, m.rows, unsolvable)
+	printSummaries(w, summaries, true)
+	return nil
+}
+
+func printSummaries(w io.Writer, summaries []solverSummary, averaged bool) {
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', tabwriter.AlignRight)
+	if averaged {
+		fmt.Fprintln(tw, "solver\tsolved\toptimal\tmean cost\tmean expanded\tmean peak frontier\tmean time\t")
+	} else {
+		fmt.Fprintln(tw, "solver\tcost\texpanded\tpeak frontier\ttime\t")
+	}
+	for _, s := range summaries {
+		n := math.Max(1, float64(s.solved))
+		if averaged {
+			fmt.Fprintf(tw, "%s\t%d\t%d\t%.2f\t%.1f\t%.1f\t%v\t\n", s.name, s.solved, s.optimal,
+				s.cost/n, float64(s.expanded)/n, float64(s.peakFrontier)/n, s.elapsed/time.Duration(n))
+		} else {
+			fmt.Fprintf(tw, "%s\t%.2f\t%d\t%d\t%v\t\n", s.name, s.cost, s.expanded, s.peakFrontier, s.elapsed)
+		}
+	}
+	tw.Flush()
+}
@@ -0,0 +1,141 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"math/rand"
+)
+
+const (
+	ROAD  byte = '='
+	MUD   byte = '%'
+	WATER byte = '~'
+)
+
+// terrainCost is the cost of entering a cell; a diagonal step costs sqrt(2) times as much.
+var terrainCost = map[byte]float64{
+	EMPTY: 1,
+	START: 1,
+	GOAL:  1,
+	PATH:  1,
+	ROAD:  0.5,
+	MUD:   3,
+	WATER: 5,
+}
+
+// cornerPolicy decides whether a diagonal step may pass beside blocked cells.
+type cornerPolicy int
+
+const (
+	cutNever cornerPolicy = iota
+	cutIfOneOpen
+	cutAlways
+)
+
+var cornerPolicies = map[string]cornerPolicy{
+	"never":  cutNever,
+	"one":    cutIfOneOpen,
+	"always": cutAlways,
+}
+
+var errInadmissible = errors.New("inadmissible heuristic")
+
+func (m maze) passable(ml mazeLocation) bool {
+	return m.inside(ml) && m.grid[m.index(ml)] != BLOCKED
+}
+
+func (m maze) diagonalAllowed(from mazeLocation, to mazeLocation) bool {
+	open := 0
+	if m.passable(mazeLocation{to.column, from.row}) {
+		open++
+	}
+	if m.passable(mazeLocation{from.column, to.row}) {
+		open++
+	}
+	switch m.corners {
+	case cutAlways:
+		return true
+	case cutIfOneOpen:
+		return open >= 1
+	}
+	return open == 2
+}
+
+func (m maze) stepCost(from mazeLocation, to mazeLocation) float64 {
+	cost := terrainCost[m.grid[m.index(to)]]
+	if from.column != to.column && from.row != to.row {
+		cost *= math.Sqrt2
+	}
+	return cost
+}
+
+func (m maze) cheapestTerrain() float64 {
+	cheapest := math.Inf(1)
+	for _, cell := range m.grid {
+		if cost, ok := terrainCost[cell]; ok && cost < cheapest {
+			cheapest = cost
+		}
+	}
+	return cheapest
+}
+
+// scatterTerrain turns each empty cell into road, mud or water with probability p.
+func (m *maze) scatterTerrain(p float64, seed int64) {
+	rnd := rand.New(rand.NewSource(seed))
+	kinds := []byte{ROAD, MUD, WATER}
+	for i, cell := range m.grid {
+		if cell == EMPTY && rnd.Float64() < p {
+			m.grid[i] = kinds[rnd.Intn(len(kinds))]
+		}
+	}
+}
+
+func euclidean(init, goal mazeLocation) float64 {
+	return math.Hypot(float64(init.column-goal.column), float64(init.row-goal.row))
+}
+
+func octile(init, goal mazeLocation) float64 {
+	xdist := math.Abs(float64(init.column) - float64(goal.column))
+	ydist := math.Abs(float64(init.row) - float64(goal.row))
+	return xdist + ydist + (math.Sqrt2-2)*math.Min(xdist, ydist)
+}
+
+func chebyshev(init, goal mazeLocation) float64 {
+	xdist := math.Abs(float64(init.column) - float64(goal.column))
+	ydist := math.Abs(float64(init.row) - float64(goal.row))
+	return math.Max(xdist, ydist)
+}
+
+var heuristics = map[string]heuristicFn{
+	"manhattan": manhattan,
+	"euclidean": euclidean,
+	"octile":    octile,
+	"chebyshev": chebyshev,
+}
+
+// heuristic returns the named distance scaled by the cheapest terrain of the maze, so it
+// never overestimates. Manhattan is refused when diagonal moves are allowed. An empty
+// name picks the tightest admissible one: manhattan or octile.
+func (m maze) heuristic(name string) (heuristicFn, error) {
+	if name == "" {
+		name = "manhattan"
+		if m.diagonal {
+			name = "octile"
+		}
+	}
+	h, ok := heuristics[name]
+	if !ok {
+		return nil, fmt.Errorf("unknown heuristic %q", name)
+	}
+	if name == "manhattan" && m.diagonal {
+		return nil, fmt.Errorf("%w: %s with diagonal moves", errInadmissible, name)
+	}
+	scale := m.cheapestTerrain()
+	if scale == 1 || math.IsInf(scale, 1) {
+		return h, nil
+	}
+	return func(pos, goal mazeLocation) float64 {
+		return scale * h(pos, goal)
+	}, nil
+}
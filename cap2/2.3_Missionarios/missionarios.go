@@ -1,6 +1,11 @@
 package main
 
-import "fmt"
+import (
+	"fmt"
+	"log"
+
+	"github.com/arlima/problemas_classicos_CC/search"
+)
 
 const (
 	MAX_NUM int = 3
@@ -94,70 +99,6 @@ func (m MCState) successors() []MCState {
 	return ret
 }
 
-type node struct {
-	state  MCState
-	parent *node
-}
-
-type queue struct {
-	container []node
-}
-
-func (q queue) empty() bool {
-	return (len(q.container) == 0)
-}
-
-func (q *queue) push(n node) {
-	q.container = append(q.container, n)
-}
-
-func (q *queue) pop() node {
-	value := q.container[0]
-	q.container = q.container[1:]
-	return value
-}
-
-func bfs(start MCState) node {
-	frontier := queue{}
-	explored := make(map[MCState]int)
-	frontier.push(node{start, nil})
-	explored[start] = 1
-
-	for !frontier.empty() {
-		currentNode := frontier.pop()
-		currentState := currentNode.state
-
-		if currentState.goalTest() {
-			return currentNode
-		}
-
-		for _, child := range currentState.successors() {
-			if _, ok := explored[child]; ok {
-				continue
-			}
-			explored[child] = 1
-			frontier.push(node{child, &currentNode})
-		}
-	}
-	return node{}
-}
-
-func nodeToPath(n node) []MCState {
-	path := []MCState{n.state}
-	for n.parent != nil {
-		n = *n.parent
-		path = append(path, n.state)
-	}
-	return path
-}
-
-func reverse(nodes []MCState) []MCState {
-	for i, j := 0, len(nodes)-1; i < j; i, j = i+1, j-1 {
-		nodes[i], nodes[j] = nodes[j], nodes[i]
-	}
-	return nodes
-}
-
 func displaySolution(path []MCState) {
 	if len(path) == 0 {
 		return
@@ -177,9 +118,16 @@ func displaySolution(path []MCState) {
 }
 
 func main() {
-	MCState := MCState{}
-	MCState.init(MAX_NUM, MAX_NUM, true)
-	fmt.Println(MCState)
-	result := bfs(MCState)
-	displaySolution(reverse(nodeToPath(result)))
+	start := MCState{}
+	start.init(MAX_NUM, MAX_NUM, true)
+	fmt.Println(start)
+	result, err := search.BFS(search.Problem[MCState]{
+		Initial:    start,
+		Successors: MCState.successors,
+		Goal:       MCState.goalTest,
+	})
+	if err != nil {
+		log.Fatal(err)
+	}
+	displaySolution(result.Path)
 }
@@ -27,7 +27,7 @@ func generateGrid(cols, rows int) grid {
 func printGrid(g grid, cols, rows int) {
 	for y := 0; y < rows; y++ {
 		for x := 0; x < cols; x++ {
-			fmt.Printf(g[x][y])
+			fmt.Print(g[x][y])
 		}
 		fmt.Printf("\n")
 	}
@@ -1,6 +1,11 @@
 package main
 
-import "fmt"
+import (
+	"fmt"
+	"log"
+
+	"github.com/arlima/problemas_classicos_CC/search"
+)
 
 type vertex struct {
 	value string
@@ -115,68 +120,12 @@ func (g graph) String() string {
 	return str
 }
 
-type node struct {
-	state  vertex
-	parent *node
-}
-
-type queue struct {
-	container []node
-}
-
-func (q queue) empty() bool {
-	return (len(q.container) == 0)
-}
-
-func (q *queue) push(n node) {
-	q.container = append(q.container, n)
-}
-
-func (q *queue) pop() node {
-	value := q.container[0]
-	q.container = q.container[1:]
-	return value
-}
-
-func bfs(initial vertex, final vertex, g graph) node {
-	frontier := queue{}
-	explored := make(map[vertex]int)
-	frontier.push(node{initial, nil})
-	explored[initial] = 1
-
-	for !frontier.empty() {
-		currentNode := frontier.pop()
-		currentState := currentNode.state
-
-		if currentState == final {
-			return currentNode
-		}
-
-		for _, child := range g.neighborsForVertex(currentState) {
-			if _, ok := explored[child]; ok {
-				continue
-			}
-			explored[child] = 1
-			frontier.push(node{child, &currentNode})
-		}
-	}
-	return node{}
-}
-
-func reverse(g []vertex) []vertex {
-	for i, j := 0, len(g)-1; i < j; i, j = i+1, j-1 {
-		g[i], g[j] = g[j], g[i]
-	}
-	return g
-}
-
-func nodeToPath(n node) []vertex {
-	path := []vertex{n.state}
-	for n.parent != nil {
-		n = *n.parent
-		path = append(path, n.state)
-	}
-	return reverse(path)
+func bfs(initial vertex, final vertex, g graph) (search.Result[vertex], error) {
+	return search.BFS(search.Problem[vertex]{
+		Initial:    initial,
+		Successors: g.neighborsForVertex,
+		Goal:       func(v vertex) bool { return v == final },
+	})
 }
 
 func main() {
@@ -211,7 +160,10 @@ func main() {
 	citiGraph.addEdgeByVertices(vertex{"New York"}, vertex{"Philadelphia"})
 	citiGraph.addEdgeByVertices(vertex{"Philadelphia"}, vertex{"Washington"})
 
-	bfsResult := bfs(vertex{"Boston"}, vertex{"Miami"}, citiGraph)
+	bfsResult, err := bfs(vertex{"Boston"}, vertex{"Miami"}, citiGraph)
+	if err != nil {
+		log.Fatal(err)
+	}
 
-	fmt.Println(nodeToPath(bfsResult))
+	fmt.Println(bfsResult.Path)
 }
@@ -25,7 +25,7 @@ func (e edge) reversed() edge {
 }
 
 func (e edge) String() string {
-	return fmt.Sprintf("%d (%f) -> %d", e.u, e.v, e.weight)
+	return fmt.Sprintf("%d (%f) -> %d", e.u, e.weight, e.v)
 }
 
 type graph struct {
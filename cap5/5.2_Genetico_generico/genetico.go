@@ -71,7 +71,7 @@ func choicesTournament(population []chromosome, participants int, qtt int) []chr
 		p := population[rand.Intn(len(population))]
 		part = append(part, chromosomeFit{p, p.fitness()})
 	}
-	sort.Reverse(part)
+	sort.Sort(sort.Reverse(part))
 	ret := []chromosome{}
 
 	for i := 0; i < qtt; i++ {
@@ -162,7 +162,7 @@ func (g geneticAlgorithm) run() chromosome {
 		if best.fitness() >= g.threshold {
 			return best
 		}
-		fmt.Println("Generation %d, Best %f, Avg %f", generation, best.fitness, 0.0)
+		fmt.Printf("Generation %d, Best %f, Avg %f\n", generation, best.fitness(), 0.0)
 		g.reproduceAndReplace()
 		g.mutate()
 		highest := g.maxFitness()
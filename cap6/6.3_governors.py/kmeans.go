@@ -1,13 +1,13 @@
 package main
 
 import (
+	"fmt"
 	"log"
 	"math"
 	"math/rand"
 
-	"github.com/gonum/floats"
-	"github.com/gonum/stat"
-	"golang.org/x/exp/errors/fmt"
+	"gonum.org/v1/gonum/floats"
+	"gonum.org/v1/gonum/stat"
 )
 
 type cluster struct {
@@ -2,6 +2,7 @@ package main
 
 import (
 	"bufio"
+	"fmt"
 	"log"
 	"math"
 	"math/rand"
@@ -9,9 +10,8 @@ import (
 	"strconv"
 	"strings"
 
-	"github.com/gonum/floats"
-	"github.com/gonum/stat"
-	"golang.org/x/exp/errors/fmt"
+	"gonum.org/v1/gonum/floats"
+	"gonum.org/v1/gonum/stat"
 )
 
 type cluster struct {
@@ -10,7 +10,7 @@ import (
 	"strings"
 	"time"
 
-	"github.com/gonum/floats"
+	"gonum.org/v1/gonum/floats"
 )
 
 func interpretOutput(output []float64) int {
@@ -0,0 +1,5 @@
+module github.com/arlima/problemas_classicos_CC
+
+go 1.24.0
+
+require gonum.org/v1/gonum v0.17.0
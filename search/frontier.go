@@ -0,0 +1,74 @@
+package search
+
+import "container/heap"
+
+type stack[S comparable] struct {
+	container []*Node[S]
+}
+
+func (s stack[S]) empty() bool {
+	return len(s.container) == 0
+}
+
+func (s *stack[S]) push(n *Node[S]) {
+	s.container = append(s.container, n)
+}
+
+func (s *stack[S]) pop() *Node[S] {
+	p := len(s.container) - 1
+	value := s.container[p]
+	s.container = s.container[:p]
+	return value
+}
+
+type queue[S comparable] struct {
+	container []*Node[S]
+}
+
+func (q queue[S]) empty() bool {
+	return len(q.container) == 0
+}
+
+func (q *queue[S]) push(n *Node[S]) {
+	q.container = append(q.container, n)
+}
+
+func (q *queue[S]) pop() *Node[S] {
+	value := q.container[0]
+	q.container[0] = nil
+	q.container = q.container[1:]
+	return value
+}
+
+// priorityQueue orders nodes by cost plus heuristic.
+type priorityQueue[S comparable] struct {
+	nodes []*Node[S]
+}
+
+func (pq priorityQueue[S]) Len() int { return len(pq.nodes) }
+
+func (pq priorityQueue[S]) Less(i, j int) bool {
+	return pq.nodes[i].Cost+pq.nodes[i].Heuristic < pq.nodes[j].Cost+pq.nodes[j].Heuristic
+}
+
+func (pq priorityQueue[S]) Swap(i, j int) {
+	pq.nodes[i], pq.nodes[j] = pq.nodes[j], pq.nodes[i]
+}
+
+func (pq *priorityQueue[S]) Push(x any) {
+	pq.nodes = append(pq.nodes, x.(*Node[S]))
+}
+
+func (pq *priorityQueue[S]) Pop() any {
+	n := len(pq.nodes)
+	item := pq.nodes[n-1]
+	pq.nodes[n-1] = nil
+	pq.nodes = pq.nodes[:n-1]
+	return item
+}
+
+func (pq priorityQueue[S]) empty() bool { return len(pq.nodes) == 0 }
+
+func (pq *priorityQueue[S]) push(n *Node[S]) { heap.Push(pq, n) }
+
+func (pq *priorityQueue[S]) pop() *Node[S] { return heap.Pop(pq).(*Node[S]) }
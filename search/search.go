@@ -0,0 +1,210 @@
+// Package search implements the uninformed and informed searches of chapter 2 over any
+// comparable state, so the maze, the missionaries and the graph examples share them.
+package search
+
+import (
+	"errors"
+	"fmt"
+	"time"
+)
+
+var ErrUnreachable = errors.New("goal unreachable")
+
+// Problem describes a state space. Cost defaults to 1 per step and Heuristic to 0.
+// Predecessors is only needed by backward searches and defaults to Successors.
+// OnExpand and OnGenerate, when set, see every state the search expands and every state
+// it adds to its frontier, in order.
+type Problem[S comparable] struct {
+	Initial      S
+	Successors   func(S) []S
+	Predecessors func(S) []S
+	Goal         func(S) bool
+	Cost         func(from, to S) float64
+	Heuristic    func(S) float64
+	OnExpand     func(S)
+	OnGenerate   func(S)
+}
+
+func (p Problem[S]) cost(from, to S) float64 {
+	if p.Cost == nil {
+		return 1
+	}
+	return p.Cost(from, to)
+}
+
+func (p Problem[S]) heuristic(s S) float64 {
+	if p.Heuristic == nil {
+		return 0
+	}
+	return p.Heuristic(s)
+}
+
+func (p Problem[S]) expand(s S) {
+	if p.OnExpand != nil {
+		p.OnExpand(s)
+	}
+}
+
+func (p Problem[S]) generate(s S) {
+	if p.OnGenerate != nil {
+		p.OnGenerate(s)
+	}
+}
+
+type Node[S comparable] struct {
+	State     S
+	Parent    *Node[S]
+	Cost      float64
+	Heuristic float64
+}
+
+// Path returns the states from the initial one to n.
+func (n *Node[S]) Path() []S {
+	path := []S{}
+	for ; n != nil; n = n.Parent {
+		path = append(path, n.State)
+	}
+	for i, j := 0, len(path)-1; i < j; i, j = i+1, j-1 {
+		path[i], path[j] = path[j], path[i]
+	}
+	return path
+}
+
+// Result is the path found with its cost, and how much work it took to find it.
+type Result[S comparable] struct {
+	Path         []S
+	Cost         float64
+	Expanded     int
+	PeakFrontier int
+	Elapsed      time.Duration
+}
+
+func (r Result[S]) String() string {
+	return fmt.Sprintf("%d steps, cost %.2f, %d expanded, peak frontier %d, %v",
+		len(r.Path)-1, r.Cost, r.Expanded, r.PeakFrontier, r.Elapsed)
+}
+
+func (r *Result[S]) observeFrontier(size int) {
+	if size > r.PeakFrontier {
+		r.PeakFrontier = size
+	}
+}
+
+// finish completes the result of a search that ended at goal, or returns ErrUnreachable
+// when goal is nil.
+func (p Problem[S]) finish(r Result[S], goal *Node[S], started time.Time) (Result[S], error) {
+	r.Elapsed = time.Since(started)
+	if goal == nil {
+		return r, fmt.Errorf("%w from %v", ErrUnreachable, p.Initial)
+	}
+	return p.finishPath(r, goal.Path(), started)
+}
+
+func (p Problem[S]) finishPath(r Result[S], path []S, started time.Time) (Result[S], error) {
+	r.Elapsed = time.Since(started)
+	r.Path = path
+	r.Cost = p.PathCost(path)
+	return r, nil
+}
+
+func (p Problem[S]) PathCost(path []S) float64 {
+	cost := 0.0
+	for i := 1; i < len(path); i++ {
+		cost += p.cost(path[i-1], path[i])
+	}
+	return cost
+}
+
+func DFS[S comparable](p Problem[S]) (Result[S], error) {
+	started := time.Now()
+	r := Result[S]{}
+	frontier := stack[S]{}
+	explored := map[S]bool{p.Initial: true}
+	p.generate(p.Initial)
+	frontier.push(&Node[S]{State: p.Initial})
+
+	for !frontier.empty() {
+		r.observeFrontier(len(frontier.container))
+		current := frontier.pop()
+		r.Expanded++
+		p.expand(current.State)
+
+		if p.Goal(current.State) {
+			return p.finish(r, current, started)
+		}
+
+		for _, child := range p.Successors(current.State) {
+			if explored[child] {
+				continue
+			}
+			explored[child] = true
+			p.generate(child)
+			frontier.push(&Node[S]{State: child, Parent: current})
+		}
+	}
+	return p.finish(r, nil, started)
+}
+
+func BFS[S comparable](p Problem[S]) (Result[S], error) {
+	started := time.Now()
+	r := Result[S]{}
+	frontier := queue[S]{}
+	explored := map[S]bool{p.Initial: true}
+	p.generate(p.Initial)
+	frontier.push(&Node[S]{State: p.Initial})
+
+	for !frontier.empty() {
+		r.observeFrontier(len(frontier.container))
+		current := frontier.pop()
+		r.Expanded++
+		p.expand(current.State)
+
+		if p.Goal(current.State) {
+			return p.finish(r, current, started)
+		}
+
+		for _, child := range p.Successors(current.State) {
+			if explored[child] {
+				continue
+			}
+			explored[child] = true
+			p.generate(child)
+			frontier.push(&Node[S]{State: child, Parent: current})
+		}
+	}
+	return p.finish(r, nil, started)
+}
+
+// AStar finds a cheapest path when the heuristic never overestimates the remaining cost.
+func AStar[S comparable](p Problem[S]) (Result[S], error) {
+	started := time.Now()
+	r := Result[S]{}
+	frontier := priorityQueue[S]{}
+	explored := map[S]float64{p.Initial: 0}
+	p.generate(p.Initial)
+	frontier.push(&Node[S]{State: p.Initial, Heuristic: p.heuristic(p.Initial)})
+
+	for !frontier.empty() {
+		r.observeFrontier(frontier.Len())
+		current := frontier.pop()
+		if current.Cost > explored[current.State] {
+			continue
+		}
+		r.Expanded++
+		p.expand(current.State)
+
+		if p.Goal(current.State) {
+			return p.finish(r, current, started)
+		}
+
+		for _, child := range p.Successors(current.State) {
+			newCost := current.Cost + p.cost(current.State, child)
+			if old, ok := explored[child]; !ok || old > newCost {
+				explored[child] = newCost
+				p.generate(child)
+				frontier.push(&Node[S]{child, current, newCost, p.heuristic(child)})
+			}
+		}
+	}
+	return p.finish(r, nil, started)
+}
@@ -0,0 +1,164 @@
+package search
+
+import (
+	"errors"
+	"math"
+	"testing"
+)
+
+// testGraph is a weighted undirected graph of ints; vertex 6 is cut off from the rest.
+var testGraph = map[int]map[int]float64{
+	0: {1: 1, 2: 4},
+	1: {0: 1, 2: 1, 3: 5},
+	2: {0: 4, 1: 1, 3: 1, 4: 7},
+	3: {1: 5, 2: 1, 4: 1},
+	4: {2: 7, 3: 1, 5: 2},
+	5: {4: 2},
+	6: {},
+}
+
+func testProblem(goal int, weighted bool) Problem[int] {
+	p := Problem[int]{
+		Initial: 0,
+		Successors: func(s int) []int {
+			next := []int{}
+			for n := 0; n < len(testGraph); n++ {
+				if _, ok := testGraph[s][n]; ok {
+					next = append(next, n)
+				}
+			}
+			return next
+		},
+		Goal: func(s int) bool { return s == goal },
+	}
+	if weighted {
+		p.Cost = func(from, to int) float64 { return testGraph[from][to] }
+	}
+	return p
+}
+
+func validPath(t *testing.T, name string, r Result[int], goal int) {
+	t.Helper()
+	if len(r.Path) == 0 || r.Path[0] != 0 || r.Path[len(r.Path)-1] != goal {
+		t.Fatalf("%s: path %v does not go from 0 to %d", name, r.Path, goal)
+	}
+	for i := 1; i < len(r.Path); i++ {
+		if _, ok := testGraph[r.Path[i-1]][r.Path[i]]; !ok {
+			t.Fatalf("%s: %d -> %d is not an edge", name, r.Path[i-1], r.Path[i])
+		}
+	}
+}
+
+func TestFewestSteps(t *testing.T) {
+	searches := map[string]func(Problem[int]) (Result[int], error){
+		"bfs":           BFS[int],
+		"iddfs":         func(p Problem[int]) (Result[int], error) { return IDDFS(p, false) },
+		"iddfs+tt":      func(p Problem[int]) (Result[int], error) { return IDDFS(p, true) },
+		"bidirectional": func(p Problem[int]) (Result[int], error) { return BidirectionalBFS(p, 5) },
+	}
+	for name, search := range searches {
+		r, err := search(testProblem(5, false))
+		if err != nil {
+			t.Fatalf("%s: %v", name, err)
+		}
+		validPath(t, name, r, 5)
+		if r.Cost != 3 {
+			t.Errorf("%s: %d steps, want 3", name, len(r.Path)-1)
+		}
+	}
+}
+
+func TestCheapestPath(t *testing.T) {
+	// Remaining cost to 5 never overestimated: the true costs are 6 5 4 3 2 0.
+	h := map[int]float64{0: 5, 1: 4, 2: 3, 3: 2, 4: 1, 5: 0}
+	searches := map[string]func(Problem[int]) (Result[int], error){
+		"astar":      AStar[int],
+		"ucs":        UniformCost[int],
+		"idastar":    func(p Problem[int]) (Result[int], error) { return IDAStar(p, false) },
+		"idastar+tt": func(p Problem[int]) (Result[int], error) { return IDAStar(p, true) },
+	}
+	for name, search := range searches {
+		p := testProblem(5, true)
+		p.Heuristic = func(s int) float64 { return h[s] }
+		r, err := search(p)
+		if err != nil {
+			t.Fatalf("%s: %v", name, err)
+		}
+		validPath(t, name, r, 5)
+		if math.Abs(r.Cost-6) > 1e-9 {
+			t.Errorf("%s: cost %v along %v, want 6", name, r.Cost, r.Path)
+		}
+	}
+}
+
+func TestAnyPath(t *testing.T) {
+	searches := map[string]func(Problem[int]) (Result[int], error){
+		"dfs":  DFS[int],
+		"beam": func(p Problem[int]) (Result[int], error) { return Beam(p, 1) },
+	}
+	for name, search := range searches {
+		r, err := search(testProblem(5, true))
+		if err != nil {
+			t.Fatalf("%s: %v", name, err)
+		}
+		validPath(t, name, r, 5)
+		if r.Cost != testProblem(5, true).PathCost(r.Path) {
+			t.Errorf("%s: cost %v does not match its path", name, r.Cost)
+		}
+	}
+}
+
+func TestUnreachable(t *testing.T) {
+	searches := map[string]func(Problem[int]) (Result[int], error){
+		"dfs":           DFS[int],
+		"bfs":           BFS[int],
+		"astar":         AStar[int],
+		"ucs":           UniformCost[int],
+		"iddfs":         func(p Problem[int]) (Result[int], error) { return IDDFS(p, false) },
+		"iddfs+tt":      func(p Problem[int]) (Result[int], error) { return IDDFS(p, true) },
+		"idastar":       func(p Problem[int]) (Result[int], error) { return IDAStar(p, false) },
+		"idastar+tt":    func(p Problem[int]) (Result[int], error) { return IDAStar(p, true) },
+		"bidirectional": func(p Problem[int]) (Result[int], error) { return BidirectionalBFS(p, 6) },
+		"beam":          func(p Problem[int]) (Result[int], error) { return Beam(p, 3) },
+	}
+	for name, search := range searches {
+		r, err := search(testProblem(6, true))
+		if !errors.Is(err, ErrUnreachable) {
+			t.Errorf("%s: got %v, want ErrUnreachable", name, err)
+		}
+		if r.Path != nil {
+			t.Errorf("%s: unreachable goal returned path %v", name, r.Path)
+		}
+	}
+}
+
+func TestObservers(t *testing.T) {
+	p := testProblem(5, false)
+	expanded, generated := 0, map[int]bool{}
+	p.OnExpand = func(int) { expanded++ }
+	p.OnGenerate = func(s int) { generated[s] = true }
+	r, err := BFS(p)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if expanded != r.Expanded {
+		t.Errorf("OnExpand saw %d states, result reports %d", expanded, r.Expanded)
+	}
+	for _, s := range r.Path {
+		if !generated[s] {
+			t.Errorf("state %d on the path was never generated", s)
+		}
+	}
+}
+
+func TestBeamRevisitsPrunedStates(t *testing.T) {
+	// With width 1 the first layer keeps 1 and drops 2, which is reached again through 1.
+	r, err := Beam(testProblem(2, true), 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	validPath(t, "beam", r, 2)
+	if r.Cost != 2 {
+		t.Errorf("beam: cost %v along %v, want 2", r.Cost, r.Path)
+	}
+}
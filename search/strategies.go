@@ -0,0 +1,265 @@
+package search
+
+import (
+	"fmt"
+	"math"
+	"sort"
+	"time"
+)
+
+// UniformCost is A* without a heuristic: it expands states in order of path cost.
+func UniformCost[S comparable](p Problem[S]) (Result[S], error) {
+	p.Heuristic = nil
+	return AStar(p)
+}
+
+// IDDFS runs depth-limited searches with growing limits, so it finds a path with the
+// fewest steps while keeping only the current path, which it checks for cycles. With
+// transpositions a state reached again at a depth no smaller than before is pruned too:
+// each iteration stays linear on graphs with many cycles, but the table of visited states
+// grows with the graph and counts towards the peak frontier.
+func IDDFS[S comparable](p Problem[S], transpositions bool) (Result[S], error) {
+	started := time.Now()
+	r := Result[S]{}
+	for limit := 0; ; limit++ {
+		var depths map[S]int
+		if transpositions {
+			depths = map[S]int{p.Initial: 0}
+		}
+		path := []S{p.Initial}
+		onPath := map[S]bool{p.Initial: true}
+		cutoff := false
+		var visit func(s S, depth int) bool
+		visit = func(s S, depth int) bool {
+			r.Expanded++
+			p.expand(s)
+			r.observeFrontier(len(path) + len(depths))
+			if p.Goal(s) {
+				return true
+			}
+			if depth == limit {
+				cutoff = true
+				return false
+			}
+			for _, child := range p.Successors(s) {
+				if onPath[child] {
+					continue
+				}
+				if depths != nil {
+					if d, ok := depths[child]; ok && d <= depth+1 {
+						continue
+					}
+					depths[child] = depth + 1
+				}
+				p.generate(child)
+				path = append(path, child)
+				onPath[child] = true
+				if visit(child, depth+1) {
+					return true
+				}
+				path = path[:len(path)-1]
+				delete(onPath, child)
+			}
+			return false
+		}
+		p.generate(p.Initial)
+		if visit(p.Initial, 0) {
+			return p.finishPath(r, path, started)
+		}
+		if !cutoff {
+			return p.finish(r, nil, started)
+		}
+	}
+}
+
+// IDAStar repeats depth-first searches bounded by cost plus heuristic, raising the bound
+// to the smallest value that exceeded it. Children are tried cheapest first and states
+// already on the current path are skipped. With transpositions, as in IDDFS, a state
+// reached again without a cheaper cost is pruned for the rest of the iteration and the
+// table counts towards the peak frontier.
+func IDAStar[S comparable](p Problem[S], transpositions bool) (Result[S], error) {
+	started := time.Now()
+	r := Result[S]{}
+	for bound := p.heuristic(p.Initial); ; {
+		var costs map[S]float64
+		if transpositions {
+			costs = map[S]float64{p.Initial: 0}
+		}
+		path := []S{p.Initial}
+		onPath := map[S]bool{p.Initial: true}
+		next := math.Inf(1)
+		p.generate(p.Initial)
+		var visit func(s S, cost float64) bool
+		visit = func(s S, cost float64) bool {
+			f := cost + p.heuristic(s)
+			if f > bound {
+				next = math.Min(next, f)
+				return false
+			}
+			r.Expanded++
+			p.expand(s)
+			r.observeFrontier(len(path) + len(costs))
+			if p.Goal(s) {
+				return true
+			}
+			children := []*Node[S]{}
+			for _, child := range p.Successors(s) {
+				if onPath[child] {
+					continue
+				}
+				childCost := cost + p.cost(s, child)
+				if costs != nil {
+					if c, ok := costs[child]; ok && c <= childCost {
+						continue
+					}
+					costs[child] = childCost
+				}
+				p.generate(child)
+				children = append(children, &Node[S]{child, nil, childCost, p.heuristic(child)})
+			}
+			sort.SliceStable(children, func(i, j int) bool {
+				return children[i].Cost+children[i].Heuristic < children[j].Cost+children[j].Heuristic
+			})
+			for _, child := range children {
+				if costs != nil && costs[child.State] < child.Cost {
+					continue
+				}
+				path = append(path, child.State)
+				onPath[child.State] = true
+				if visit(child.State, child.Cost) {
+					return true
+				}
+				path = path[:len(path)-1]
+				delete(onPath, child.State)
+			}
+			return false
+		}
+		if visit(p.Initial, 0) {
+			return p.finishPath(r, path, started)
+		}
+		if math.IsInf(next, 1) {
+			return p.finish(r, nil, started)
+		}
+		bound = next
+	}
+}
+
+// BidirectionalBFS searches from the initial state and backwards from goal one layer at
+// a time, always growing the smaller side, and joins the two trees where they meet.
+func BidirectionalBFS[S comparable](p Problem[S], goal S) (Result[S], error) {
+	started := time.Now()
+	r := Result[S]{}
+	predecessors := p.Predecessors
+	if predecessors == nil {
+		predecessors = p.Successors
+	}
+	type side struct {
+		parents  map[S]S
+		depths   map[S]int
+		frontier []S
+		expand   func(S) []S
+	}
+	forward := &side{map[S]S{}, map[S]int{p.Initial: 0}, []S{p.Initial}, p.Successors}
+	backward := &side{map[S]S{}, map[S]int{goal: 0}, []S{goal}, predecessors}
+
+	p.generate(p.Initial)
+	p.generate(goal)
+	meet, found := p.Initial, p.Initial == goal
+	for !found && len(forward.frontier) > 0 && len(backward.frontier) > 0 {
+		r.observeFrontier(len(forward.frontier) + len(backward.frontier))
+		this, other := forward, backward
+		if len(backward.frontier) < len(forward.frontier) {
+			this, other = backward, forward
+		}
+		// Finish the whole layer so the meeting point with the shortest total is kept.
+		best := math.MaxInt
+		next := []S{}
+		for _, s := range this.frontier {
+			r.Expanded++
+			p.expand(s)
+			for _, child := range this.expand(s) {
+				if _, ok := this.depths[child]; ok {
+					continue
+				}
+				this.depths[child] = this.depths[s] + 1
+				this.parents[child] = s
+				p.generate(child)
+				next = append(next, child)
+				if d, ok := other.depths[child]; ok && this.depths[child]+d < best {
+					best = this.depths[child] + d
+					meet, found = child, true
+				}
+			}
+		}
+		this.frontier = next
+	}
+	if !found {
+		return p.finish(r, nil, started)
+	}
+
+	path := []S{meet}
+	for s := meet; s != p.Initial; {
+		s = forward.parents[s]
+		path = append(path, s)
+	}
+	for i, j := 0, len(path)-1; i < j; i, j = i+1, j-1 {
+		path[i], path[j] = path[j], path[i]
+	}
+	for s := meet; s != goal; {
+		s = backward.parents[s]
+		path = append(path, s)
+	}
+	return p.finishPath(r, path, started)
+}
+
+// Beam expands a layer at a time but keeps only the width most promising states of each
+// layer by cost plus heuristic. The frontier is bounded by width, but every state kept in a
+// beam is remembered so cycles end; it may miss the goal or return a longer path than
+// necessary.
+func Beam[S comparable](p Problem[S], width int) (Result[S], error) {
+	if width < 1 {
+		return Result[S]{}, fmt.Errorf("invalid beam width %d", width)
+	}
+	started := time.Now()
+	r := Result[S]{}
+	explored := map[S]bool{p.Initial: true}
+	layer := []*Node[S]{{State: p.Initial, Heuristic: p.heuristic(p.Initial)}}
+	p.generate(p.Initial)
+
+	for len(layer) > 0 {
+		r.observeFrontier(len(layer))
+		next := []*Node[S]{}
+		for _, current := range layer {
+			r.Expanded++
+			p.expand(current.State)
+			if p.Goal(current.State) {
+				return p.finish(r, current, started)
+			}
+			for _, child := range p.Successors(current.State) {
+				if explored[child] {
+					continue
+				}
+				cost := current.Cost + p.cost(current.State, child)
+				next = append(next, &Node[S]{child, current, cost, p.heuristic(child)})
+			}
+		}
+		sort.SliceStable(next, func(i, j int) bool {
+			return next[i].Cost+next[i].Heuristic < next[j].Cost+next[j].Heuristic
+		})
+		// Only the states that make it into the beam count as explored; a state reached
+		// twice in the same layer keeps its cheapest node.
+		layer = []*Node[S]{}
+		for _, n := range next {
+			if len(layer) == width {
+				break
+			}
+			if explored[n.State] {
+				continue
+			}
+			explored[n.State] = true
+			p.generate(n.State)
+			layer = append(layer, n)
+		}
+	}
+	return p.finish(r, nil, started)
+}